@@ -55,7 +55,10 @@ var (
 	optionCategoryWhat    = newOptionCategory("What to search",
 		`For multiple glob patterns, use ';' as the delimiter, e.g. "*.cfg; *.txt; *.go". Leading and trailing spaces in each sub-expression will be ignored.
 Note that when you use the '*' and '?' pattern strings from the command line, they may be escaped by the command shell before `+longProgramName+` is invoked. Therefore it is best to always enclose these patterns with double-quotes, e.g. -I="*.txt", or "-I=*.txt".
-For detailed syntax of glob patterns, please see: https://golang.org/pkg/path/filepath/#Match`)
+Glob patterns without a slash '/' match the base names of files or dirs, while patterns with a slash match their paths relative to the starting dir, e.g. "src/**/test/*.go", where "**" matches any number of dirs. Both kinds accept alternatives in braces, e.g. "*.{ts,tsx}". A pattern starting with '!' cancels the earlier patterns that match, e.g. "*.go; !*_test.go".
+For detailed syntax of glob patterns, please see: https://golang.org/pkg/path/filepath/#Match
+Sizes are in bytes, or may end with K, M, G or T for binary kilobytes, megabytes, gigabytes or terabytes, e.g. 10K or 1.5M.
+Dates are in local time, e.g. 2026-01-01 or "2026-01-01 13:30". Ages count back from now using s, m, h, d (days) and w (weeks), e.g. 7d or 1h30m.`)
 	optionCategoryMatching = newOptionCategory("How to match search string",
 		`For detailed syntax of regex patterns, please see: https://golang.org/pkg/regexp/syntax/`)
 	optionCategoryOutputDisplay = newOptionCategory("How to display output", "")
@@ -103,6 +106,11 @@ type stringOption struct {
 	isGiven      bool
 	defaultValue string
 	value        string
+
+	// Repeatable options keep every value given in the same source.
+	isRepeatable     bool
+	values           []string
+	valuesSourceName string
 }
 
 type anyOption interface {
@@ -136,6 +144,9 @@ var (
 	optionInfo = newBoolOption(optionCategoryGeneral,
 		"info", "-?|--info",
 		"print full help information", false)
+	optionListTypes = newBoolOption(optionCategoryGeneral,
+		"list-types", "-lt|--list-types",
+		"print the named file types for -Y and -YX, including the ones defined with -YD", false)
 	optionVersion = newBoolOption(optionCategoryGeneral,
 		"version", "-vs|--version",
 		"print version and exit", false)
@@ -151,14 +162,59 @@ var (
 	optionMarkDown = newBoolOption(optionCategoryGeneral,
 		"markdown", "-md|--markdown",
 		"print help information in markdown format", false)
+	optionJobs = newIntOption(optionCategoryGeneral,
+		"jobs", "-J|--jobs=[0:1024]",
+		"number of dirs and files to read at the same time; default is 0 to use the number of CPUs", 0)
+	optionWatch = newBoolOption(optionCategoryGeneral,
+		"watch", "-W|--watch",
+		"after searching, keep watching the dirs and files searched, and print the results that appear "+
+			"or disappear as they change; uses inotify on Linux, or else looks for changes every few seconds", false)
 
 	// Where.
-	optionDir = newStringOption(optionCategoryWhere,
+	optionDir = newRepeatableStringOption(optionCategoryWhere,
 		"dir", "-D|--dir=[starting-dir]",
-		"starting dir to search, defaults to the current dir \".\"", ".")
+		"starting dir to search, defaults to the current dir \".\", or to the standard input when data is piped in; "+
+			"may be given more than once, and may also be a file to search, or \"-\" for the standard input", ".")
+	optionFilesFrom = newStringOption(optionCategoryWhere,
+		"files-from", "-FF|--files-from=[filename]",
+		"search the dirs and files listed in the given file, or in the standard input if \"-\", "+
+			"without walking into the dirs; paths are separated by newlines, or by NUL characters "+
+			"as from \"git ls-files -z\" and \"find -print0\"", "")
 	optionMaxLevels = newIntOption(optionCategoryWhere,
 		"max-levels", "-M|--max-levels=[-1:"+strconv.Itoa(math.MaxInt32)+"]",
 		"search up to given dir depth, 0 to search starting dir only; default of -1 means no limit", -1)
+	optionFollowLinks = newBoolOption(optionCategoryWhere,
+		"follow-links", "-follow|--follow-links",
+		"follow symbolic links to dirs and files, searching each real dir and file only once; "+
+			"links back to a parent dir are skipped", false)
+	optionDedupeFiles = newBoolOption(optionCategoryWhere,
+		"dedupe-files", "-dedupe|--dedupe-files",
+		"search each real dir and file only once, however many hard links or bind mounts lead to it; "+
+			"the first path found is kept; not available on Windows and other systems without inode numbers, "+
+			"where it has no effect", false)
+	optionShowDuplicates = newBoolOption(optionCategoryWhere,
+		"show-duplicates", "-sd|--show-duplicates",
+		"print each path skipped by -dedupe or -follow, with the path searched instead", false)
+	optionGit = newStringOption(optionCategoryWhere,
+		"git", "-G|--git=[tracked|modified|staged|untracked]",
+		"instead of walking the starting dirs, search the files that git lists in them: tracked files, "+
+			"modified files not yet committed, staged files, or untracked files that are not ignored; "+
+			"use ';' to give more than one kind", "")
+	optionGitSince = newStringOption(optionCategoryWhere,
+		"git-since", "-GS|--git-since=[revision]",
+		"instead of walking the starting dirs, search the files changed since the given git revision, "+
+			"such as the branch that the current branch started from, including changes not yet committed "+
+			"but not new files that git does not track yet", "")
+	optionOneFileSystem = newBoolOption(optionCategoryWhere,
+		"one-file-system", "-xdev|--one-file-system",
+		"don't search dirs on other file systems than the starting dir, printing each mount point skipped", false)
+	optionExcludeFSTypes = newStringOption(optionCategoryWhere,
+		"exclude-fstype", "-XT|--exclude-fstype=[fstype]",
+		"don't search the mount points of the given file system types, e.g. \"proc; sysfs\"; "+
+			"uses /proc/self/mountinfo, and so only works on Linux", "")
+	optionBrokenLinks = newBoolOption(optionCategoryWhere,
+		"broken-links", "-bl|--broken-links",
+		"print each symbolic link whose target does not exist, with its target", false)
 
 	// What.
 	optionSearchNamesOnly = newBoolOption(optionCategoryWhat,
@@ -179,6 +235,62 @@ var (
 	optionExcludeDirs = newStringOption(optionCategoryWhat,
 		"exclude-dirs", "-XD|--exclude-dirs=[glob-pattern]",
 		"exclude glob pattern for dirs", "")
+	optionIncludeTypes = newStringOption(optionCategoryWhat,
+		"include-types", "-Y|--include-types=[type-names]",
+		"search only the files of the given named types, e.g. \"go,py\"; files without an extension are also "+
+			"recognized by their \"#!\" line; use -lt to list the types", "")
+	optionExcludeTypes = newStringOption(optionCategoryWhat,
+		"exclude-types", "-YX|--exclude-types=[type-names]",
+		"don't search the files of the given named types, e.g. \"web\"", "")
+	optionDefineTypes = newStringOption(optionCategoryWhat,
+		"define-types", "-YD|--define-types=[type-definitions]",
+		"define more named file types, or replace the built-in ones, e.g. \"proto:*.proto; deno:*.ts,#!deno\", "+
+			"where \"#!name\" gives an interpreter; best kept in the config file with -S", "")
+	optionTypes = newStringOption(optionCategoryWhat,
+		"type", "-ty|--type=[f|d|l|p|s|b|c]",
+		"list and search only files (f), dirs (d), symbolic links (l), named pipes (p), sockets (s), "+
+			"block devices (b) or character devices (c), e.g. \"fl\"; pipes, sockets and devices are never opened, "+
+			"and are skipped unless given here", "")
+	optionSearchArchives = newBoolOption(optionCategoryWhat,
+		"search-archives", "-archives|--search-archives",
+		"also search the entries of zip, jar, war, tar, tar.gz and tgz archives, shown as \"outer.zip!/inner/path.txt\"", false)
+	optionArchiveDepth = newIntOption(optionCategoryWhat,
+		"archive-depth", "-AD|--archive-depth=[1:100]",
+		"number of levels of archives within archives to search, 1 to search only the archives in the dirs", 3)
+	optionDecompress = newBoolOption(optionCategoryWhat,
+		"decompress", "-z|--decompress",
+		"search the decompressed contents of gzip, bzip2 and zlib compressed files", false)
+	optionMaxLineLength = newStringOption(optionCategoryWhat,
+		"max-line-length", "-MLL|--max-line-length=[chunk|truncate|skip][:size]",
+		"what to do with lines longer than the given size, 1M by default, such as in minified files: "+
+			"search them in chunks of that size (default), search only their beginning, or skip them, "+
+			"e.g. \"truncate:64K\"; matches across two chunks are not found", "")
+	optionMinSize = newStringOption(optionCategoryWhat,
+		"min-size", "-MNS|--min-size=[size]",
+		"search files of at least the given size only, e.g. 10K", "")
+	optionMaxSize = newStringOption(optionCategoryWhat,
+		"max-size", "-MXS|--max-size=[size]",
+		"search files of at most the given size only, e.g. 5M", "")
+	optionNewer = newStringOption(optionCategoryWhat,
+		"newer", "-NW|--newer=[date-or-age]",
+		"search files modified after the given date or within the given age only, e.g. 2026-01-01 or 7d", "")
+	optionOlder = newStringOption(optionCategoryWhat,
+		"older", "-OL|--older=[date-or-age]",
+		"search files modified before the given date or longer ago than the given age only, e.g. 2026-01-01 or 7d", "")
+	optionNewerThan = newStringOption(optionCategoryWhat,
+		"newer-than", "-NT|--newer-than=[filename]",
+		"search files modified after the given file only", "")
+	optionNoIgnoreFiles = newBoolOption(optionCategoryWhat,
+		"no-ignore-files", "-ni|--no-ignore-files",
+		"also search dirs and files listed in .gitignore, .ignore and .ffignore files, and in the global ignore file; "+
+			"by default they will be skipped, and so will .git dirs", false)
+	optionGlobalIgnoreFile = newStringOption(optionCategoryWhat,
+		"global-ignore-file", "-GI|--global-ignore-file=[filename]",
+		"ignore file that applies to every search; defaults to git's core.excludesFile setting, or else "+
+			"\"$XDG_CONFIG_HOME/git/ignore\" or \"$HOME/.config/git/ignore\"", "")
+	optionShowIgnored = newBoolOption(optionCategoryWhat,
+		"show-ignored", "-si|--show-ignored",
+		"print each dir and file skipped because of an ignore file, with the ignore rule that skipped it", false)
 
 	// Matching.
 	optionIgnoreCase = newBoolOption(optionCategoryMatching,
@@ -190,11 +302,36 @@ var (
 	optionRegex = newBoolOption(optionCategoryMatching,
 		"regex", "-r|--regex",
 		"treat search strings as regular expressions", false)
+	optionMultiline = newBoolOption(optionCategoryMatching,
+		"multiline", "-ml|--multiline",
+		"search whole files instead of each line, so that matches can span lines, "+
+			"and report every match of the search strings in the files that contain all of them; "+
+			"in regexes, '^' and '$' match at line boundaries, and '.' matches a line end only with (?s)", false)
+	optionHex = newBoolOption(optionCategoryMatching,
+		"hex", "-x|--hex",
+		"treat search strings as hex byte patterns like \"DE AD ?? EF\", where ?? matches any byte, "+
+			"and search the raw bytes of all files, printing a hexdump around each match; %l is then the byte offset", false)
+	optionStrings = newBoolOption(optionCategoryMatching,
+		"strings", "-str|--strings",
+		"search the printable strings found in any file instead of its lines, like the strings tool, "+
+			"including UTF-16LE strings; %l is then the byte offset of each string", false)
+	optionMinStringLength = newIntOption(optionCategoryMatching,
+		"min-string-length", "-strl|--min-string-length=[1:1024]",
+		"minimum number of characters in the strings searched with -str|--strings", 4)
+	optionRecord = newStringOption(optionCategoryMatching,
+		"record", "-RS|--record=[paragraph|nul|regex:expr]",
+		"match records instead of lines: paragraphs separated by blank lines, NUL-separated records, "+
+			"or records starting at each line matching the regex, like the first line of log entries; "+
+			"%l is then the line each record starts on, and context lines are context records", "")
 	optionExcludeStrings = newStringOption(optionCategoryMatching,
 		"exclude", "-EX|--exclude-strings=[strings-to-exclude]",
 		"exclude lines containing given strings, delimited by ';'", "")
 
 	// Output display.
+	optionShowErrors = newBoolOption(optionCategoryOutputDisplay,
+		"show-errors", "-se|--show-errors",
+		"print the dirs and files that could not be read, with the reason, after the search; "+
+			"only their number is printed by default; either way, the exit status is 2 when the search is incomplete", false)
 	optionMeasureStats = newBoolOption(optionCategoryOutputDisplay,
 		"measure-stats", "-m|--measure-stats",
 		"measure time taken and number of bytes read", false)
@@ -213,6 +350,15 @@ var (
 	optionSearchBinaryFiles = newBoolOption(optionCategoryOutputDisplay,
 		"search-binary-files", "-bin|--search-binary-files",
 		"include binary files in the search; by default they will be skipped", false)
+	optionBinaryMatches = newBoolOption(optionCategoryOutputDisplay,
+		"binary-matches", "-bm|--binary-matches",
+		"print \"binary file X matches at byte offset N\" for each matching binary file, "+
+			"instead of skipping binary files or printing their contents", false)
+	optionEncoding = newStringOption(optionCategoryOutputDisplay,
+		"encoding", "-EN|--encoding=[encoding]",
+		"decode all files from the given encoding: utf-8, utf-16le, utf-16be, utf-32le, utf-32be, latin-1 "+
+			"or windows-1252; by default the encoding of each file is told by its byte order mark, "+
+			"or guessed from its first bytes", "")
 	optionShowControlChars = newBoolOption(optionCategoryOutputDisplay,
 		"show-control-chars", "-cc|--show-control-chars",
 		"show all control characters as-is; control characters here are defined as ASCII characters 0-8, 11-12, 14-31, 127",
@@ -229,6 +375,10 @@ var (
 	optionInvertMatch = newBoolOption(optionCategoryOutputDisplay,
 		"invert-match", "-v|--invert-match",
 		"print non-matching lines or file/dir names only", false)
+	optionUnordered = newBoolOption(optionCategoryOutputDisplay,
+		"unordered", "-u|--unordered",
+		"print results as soon as they are found instead of in dir tree order; result numbers and "+
+			"pagination will follow the printed order", false)
 	optionQuiet = newBoolOption(optionCategoryOutputDisplay,
 		"quiet", "-q|--quiet",
 		"turn off supporting messages", false)
@@ -246,7 +396,7 @@ var (
 		"use one-liner compact format per match: \""+outputFormat1+"\"", false)
 	optionFormat2ShowFileNamesAndCounts = newBoolOption(optionCategoryOutputDisplay,
 		"format2", "-2|--format2|--show-filenames-and-counts",
-		"print matching filenames with match counts", false)
+		"print matching filenames with match counts, and the encoding of the files that are not in utf-8", false)
 	optionFormat3ShowFileNamesOnly = newBoolOption(optionCategoryOutputDisplay,
 		"format3", "-3|--format3|--show-filenames-only",
 		"print matching filenames only", false)
@@ -284,6 +434,7 @@ var (
 var disallowedConfigOptions = map[string]bool{
 	optionHelp.name:        true,
 	optionListOptions.name: true,
+	optionListTypes.name:   true,
 	optionInfo.name:        true,
 	optionMarkDown.name:    true,
 	optionVersion.name:     true,
@@ -369,6 +520,20 @@ func newStringOption(category optionCategory, name, flags, description, defaultV
 	return &newOption
 }
 
+func newRepeatableStringOption(category optionCategory, name, flags, description, defaultValue string) *stringOption {
+	newOption := newStringOption(category, name, flags, description, defaultValue)
+	newOption.isRepeatable = true
+	return newOption
+}
+
+// Returns every value given for a repeatable option, or else its only value.
+func (o *stringOption) getValues() []string {
+	if len(o.values) == 0 {
+		return []string{o.value}
+	}
+	return o.values
+}
+
 /**************************************************************************/
 
 // Option utilities.
@@ -624,6 +789,15 @@ func parseAndSetArgument(argumentString, sourceName string, allowNonOptions bool
 		typedOption.isGiven = true
 		typedOption.value = value
 
+		// Values from the command line replace those from the config file.
+		if typedOption.isRepeatable {
+			if typedOption.valuesSourceName != sourceName {
+				typedOption.values = nil
+				typedOption.valuesSourceName = sourceName
+			}
+			typedOption.values = append(typedOption.values, value)
+		}
+
 	default:
 		panic(fmt.Sprintf("Bad option type: %#v", typedOption))
 	}
@@ -968,6 +1142,61 @@ func validateArguments() {
 		}
 	}
 
+	if optionHex.value {
+		for _, option := range []*boolOption{optionMultiline, optionInvertMatch, optionSearchNamesOnly} {
+			if option.value {
+				putln("Cannot specify %v and %v at the same time.", optionHex.flags, option.flags)
+				exit(1)
+			}
+		}
+		if optionRecord.value != "" {
+			putln("Cannot specify %v and %v at the same time.", optionHex.flags, optionRecord.flags)
+			exit(1)
+		}
+	}
+
+	if optionStrings.value {
+		for _, option := range []*boolOption{optionHex, optionMultiline} {
+			if option.value {
+				putln("Cannot specify %v and %v at the same time.", optionStrings.flags, option.flags)
+				exit(1)
+			}
+		}
+		if optionRecord.value != "" {
+			putln("Cannot specify %v and %v at the same time.", optionStrings.flags, optionRecord.flags)
+			exit(1)
+		}
+	}
+
+	if optionMultiline.value && (optionRecord.value != "") {
+		putln("Cannot specify %v and %v at the same time.",
+			optionMultiline.flags,
+			optionRecord.flags)
+		exit(1)
+	}
+
+	if optionMultiline.value && optionInvertMatch.value {
+		putln("Cannot specify %v and %v at the same time.",
+			optionMultiline.flags,
+			optionInvertMatch.flags)
+		exit(1)
+	}
+
+	if optionSearchBinaryFiles.value && optionBinaryMatches.value {
+		putln("Cannot specify %v and %v at the same time.",
+			optionSearchBinaryFiles.flags,
+			optionBinaryMatches.flags)
+		exit(1)
+	}
+
+	// Watching goes on forever, so there would be no output file to open.
+	if optionWatch.value && optionWriteToFile.value {
+		putln("Cannot specify %v and %v at the same time.",
+			optionWatch.flags,
+			optionWriteToFile.flags)
+		exit(1)
+	}
+
 	// Cannot set and unset config at the same time.
 	if (optionSetConfig.value != "") && (optionUnsetConfig.value != "") {
 		putln("Cannot specify %v and %v at the same time.",
@@ -91,7 +91,7 @@ func applyColoring(char int) {
 	case color2RuneBegin:
 		pushColoring(hiColor2)
 	default:
-		panic("Bad coloring rune: " + string(int(char)))
+		panic("Bad coloring rune: " + string(rune(char)))
 	}
 }
 
@@ -106,4 +106,12 @@ func putIntArrayWithColors(array []int) {
 	}
 }
 
+func putIntArrayWithoutColors(array []int) {
+	for _, char := range array {
+		if char >= 0 {
+			putc(rune(char))
+		}
+	}
+}
+
 /**************************************************************************/
@@ -35,6 +35,8 @@ const (
 	contactEmail          = "findfile.go@gmail.com"
 	websiteURL            = "https://github.com/choksheak/findfile"
 	defaultOutputFileName = "ff-output.txt"
+	stdinPath             = "-"
+	stdinFileName         = "(stdin)"
 	configSubDir          = ".findfile"
 	configFileName        = "config.txt"
 	configEnvVar          = "FINDFILE_OPTIONS"
@@ -42,6 +44,11 @@ const (
 	outputFormat0         = "%s%n"
 	outputFormat1         = "%p:%l: %s%n"
 	outputFormatDefault   = "%n%i. %p line %l col %c%n%s%n"
+	outputFormatMultiline = "%n%i. %p line %l col %c to line %L col %C%n%s%n"
+	outputFormatOffset    = "%n%i. %p offset %l%n%s%n"
+
+	// Like grep, tell when some dirs or files could not be searched.
+	incompleteSearchExitCode = 2
 )
 
 /**************************************************************************/
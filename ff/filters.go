@@ -0,0 +1,292 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"errors"
+	"ff/findfile"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**************************************************************************/
+
+// Prepare file type, size and time filters.
+
+var sizeMultipliers = map[byte]int64{
+	'K': 1 << 10,
+	'M': 1 << 20,
+	'G': 1 << 30,
+	'T': 1 << 40,
+}
+
+var ageUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+func prepareFileTypes() []findfile.FileType {
+	types, err := findfile.ParseFileTypes(optionTypes.value)
+	if err != nil {
+		putln("Bad file types %v for %v: %v", optionTypes.value, optionTypes.flags, err)
+		exit(1)
+	}
+	return types
+}
+
+func prepareFileKinds(option *stringOption) []findfile.FileKind {
+	kindsByName := getFileKindsByName()
+	var kinds []findfile.FileKind
+	for _, name := range splitAndTrim(strings.Replace(option.value, ",", ";", -1), ";") {
+		kind, ok := kindsByName[name]
+		if !ok {
+			putln("Unknown file type %v for %v. Use %v to list the file types.", name, option.flags, optionListTypes.flags)
+			exit(1)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// Returns the built-in file types, and the ones defined by the user.
+func getFileKindsByName() map[string]findfile.FileKind {
+	kindsByName := make(map[string]findfile.FileKind)
+	for _, kind := range findfile.DefaultFileKinds {
+		kindsByName[kind.Name] = kind
+	}
+	for _, kind := range parseFileKindDefinitions() {
+		kindsByName[kind.Name] = kind
+	}
+	return kindsByName
+}
+
+// Parses definitions such as "proto:*.proto; deno:*.ts,#!deno".
+func parseFileKindDefinitions() []findfile.FileKind {
+	var kinds []findfile.FileKind
+	for _, definition := range splitAndTrimOptionValue(optionDefineTypes) {
+		pos := strings.IndexAny(definition, ":=")
+		if pos <= 0 {
+			putln("Bad file type definition %v for %v: expecting \"name:glob,glob\".", definition, optionDefineTypes.flags)
+			exit(1)
+		}
+
+		kind := findfile.FileKind{Name: strings.TrimSpace(definition[:pos])}
+		for _, pattern := range splitOutsideBraces(definition[pos+1:], ',') {
+			pattern = strings.TrimSpace(pattern)
+			if strings.HasPrefix(pattern, "#!") {
+				kind.Interpreters = append(kind.Interpreters, strings.TrimSpace(pattern[2:]))
+			} else if pattern != "" {
+				kind.Globs = append(kind.Globs, pattern)
+			}
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// Splits the string, except within "{a,b}" glob alternatives.
+func splitOutsideBraces(s string, separator byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case separator:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Reads values such as "truncate", "64K" or "skip:1M".
+func prepareMaxLineLength() (findfile.LongLinePolicy, int) {
+	policy, maxLength := findfile.LongLinesChunk, 0
+	for _, part := range strings.Split(optionMaxLineLength.value, ":") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if p, err := findfile.ParseLongLinePolicy(part); err == nil {
+			policy = p
+			continue
+		}
+
+		size, err := parseSize(part)
+		if (err != nil) || (size <= 0) || (size > math.MaxInt32) {
+			putln("Bad value %v for %v: expecting chunk, truncate or skip, and a size.", part, optionMaxLineLength.flags)
+			exit(1)
+		}
+		maxLength = int(size)
+	}
+	return policy, maxLength
+}
+
+// Reads the record separator name, followed by the regex for "regex:".
+func prepareRecords() (findfile.RecordSeparator, string) {
+	if optionRecord.value == "" {
+		return findfile.RecordsLines, ""
+	}
+	name, expr := optionRecord.value, ""
+	if pos := strings.Index(name, ":"); pos >= 0 {
+		name, expr = name[:pos], name[pos+1:]
+	}
+	separator, err := findfile.ParseRecordSeparator(strings.TrimSpace(name))
+	if (err != nil) || ((separator == findfile.RecordsRegex) != (expr != "")) {
+		putln("Bad value %v for %v: expecting paragraph, nul or regex:expr.", optionRecord.value, optionRecord.flags)
+		exit(1)
+	}
+	if _, err := regexp.Compile(expr); err != nil {
+		putln("Bad regex %v for %v: %v", expr, optionRecord.flags, err)
+		exit(1)
+	}
+	isSearchingRecords = (separator != findfile.RecordsLines)
+	return separator, expr
+}
+
+func prepareSizeOption(option *stringOption) int64 {
+	if option.value == "" {
+		return 0
+	}
+	size, err := parseSize(option.value)
+	if err != nil {
+		putln("Bad size %v for %v: %v", option.value, option.flags, err)
+		exit(1)
+	}
+	return size
+}
+
+func prepareTimeOption(option *stringOption, now time.Time) time.Time {
+	if option.value == "" {
+		return time.Time{}
+	}
+	t, err := parseDateOrAge(option.value, now)
+	if err != nil {
+		putln("Bad date or age %v for %v: %v", option.value, option.flags, err)
+		exit(1)
+	}
+	return t
+}
+
+func prepareFileTimeOption(option *stringOption) time.Time {
+	if option.value == "" {
+		return time.Time{}
+	}
+	fileInfo, err := os.Stat(option.value)
+	if err != nil {
+		putln("Cannot read file %v for %v: %v", option.value, option.flags, err)
+		exit(1)
+	}
+	return fileInfo.ModTime()
+}
+
+// Sizes are in bytes, or else in binary kilobytes, megabytes, gigabytes
+// or terabytes when ending with K, M, G or T, e.g. "10K" or "1.5MB".
+func parseSize(str string) (int64, error) {
+	number := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(str)), "B")
+	multiplier := int64(1)
+	if n := len(number); n > 0 && sizeMultipliers[number[n-1]] != 0 {
+		multiplier = sizeMultipliers[number[n-1]]
+		number = number[:n-1]
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, errors.New("expecting a number of bytes, optionally ending with K, M, G or T")
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// A date is given in local time, e.g. "2026-01-01" or "2026-01-01 13:30",
+// and an age is counted back from now, e.g. "7d", "12h" or "1h30m".
+func parseDateOrAge(str string, now time.Time) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	age, err := parseAge(str)
+	if err != nil {
+		return time.Time{}, errors.New("expecting a date like 2026-01-01 or an age like 7d")
+	}
+	return now.Add(-age), nil
+}
+
+// Ages are a series of numbers with units s, m, h, d (days) or w (weeks).
+func parseAge(str string) (time.Duration, error) {
+	if str == "" {
+		return 0, errors.New("empty age")
+	}
+
+	var age time.Duration
+	for str != "" {
+		i := 0
+		for i < len(str) && '0' <= str[i] && str[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(str) && (str[j] < '0' || str[j] > '9') {
+			j++
+		}
+
+		number, err := strconv.Atoi(str[:i])
+		unit, ok := ageUnits[str[i:j]]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("bad age %v", str)
+		}
+		age += time.Duration(number) * unit
+		str = str[j:]
+	}
+	return age, nil
+}
+
+/**************************************************************************/
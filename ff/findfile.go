@@ -26,13 +26,19 @@ package main
 
 import (
 	"bufio"
-	"container/list"
+	"bytes"
+	"context"
+	"errors"
+	"ff/findfile"
+	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"math"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -47,23 +53,30 @@ var (
 	writeNoisyOutput               = func(format string, a ...interface{}) {}
 	readableSearchString           string
 	searchStartTime                time.Time
-	numDirsRead                    int64
-	numFilesRead                   int64
-	numBytesRead                   int64
+	searcher                       *findfile.Searcher
+	startingRoots                  []string
+	searchStdin                    bool
+	listedPaths                    []string
+	searchRootsDescription         string
 	outputFileHandle               *os.File
 	outputFileWriter               *bufio.Writer
 	outputFileInfo                 os.FileInfo
-	currentFilePath                string
-	currentLineText                string
+	currentResult                  *findfile.Result
 	contextLineIntArrayTempBuffer  []int
 	matchingLineIntArrayTempBuffer []int
 	outputFileBaseName             string
 	currentMatchesPhrase           string
 	currentNumResults              int
 	lastResultNumberToInclude      int
-	finishSearching                bool
+	searchErrors                   []searchError
 )
 
+// A dir or file that could not be read.
+type searchError struct {
+	path   string
+	reason string
+}
+
 /**************************************************************************/
 
 // Do pre-search actions if any.
@@ -78,6 +91,10 @@ func performArgumentActions() {
 		printListOfOptions()
 		needExit = true
 	}
+	if optionListTypes.value {
+		printListOfTypes()
+		needExit = true
+	}
 	if optionInfo.value {
 		printInfo()
 		needExit = true
@@ -117,16 +134,19 @@ func performSearch() {
 	prepareReadableSearchString()
 	prepareStartingDir()
 	setupOutputFile()
-	setupContextLines()
 	setupContextLineTempBuffer()
-	prepareNameIncludeExcludeFilters()
-	prepareRegexMatching()
 	prepareOutputFormat()
+	prepareSearcher()
 	setupResultsPagination()
+	setupWatching()
 	startTiming()
 	startSearching()
 	printTiming()
 	finalizeOutputFile()
+
+	if optionWatch.value {
+		watchForChanges()
+	}
 }
 
 func setupNoisyOutput() {
@@ -149,19 +169,131 @@ func prepareReadableSearchString() {
 }
 
 func prepareStartingDir() {
-	// Check that dir exists.
-	if optionDir.value != "." {
-		exists, err := pathExists(optionDir.value)
-		if !exists {
-			putln("Given starting dir \"%v\" does not exists: %v", optionDir.value, err)
-			exit(1)
+	// The current dir is only searched by default when there is no list of
+	// files to search instead.
+	if optionDir.isGiven || (optionFilesFrom.value == "") {
+		startingRoots = optionDir.getValues()
+	}
+
+	// Search the standard input when given as "-", or when data is piped in
+	// and there is nothing else to search.
+	for pos := 0; pos < len(startingRoots); pos++ {
+		if startingRoots[pos] == stdinPath {
+			searchStdin = true
+			startingRoots = append(startingRoots[:pos], startingRoots[pos+1:]...)
+			pos--
 		}
 	}
+	if !optionDir.isGiven && (optionFilesFrom.value == "") && !optionListAll.value && !isGitSelectionGiven() &&
+		isStdinRedirected() {
+		searchStdin = true
+		startingRoots = nil
+	}
+	if searchStdin && (optionFilesFrom.value == stdinPath) {
+		putln("Cannot search the standard input and read the list of files from it at the same time.")
+		exit(1)
+	}
+
+	// Check that dirs and files exist.
+	for _, root := range startingRoots {
+		if root != "." {
+			exists, err := pathExists(root)
+			if !exists {
+				putln("Given starting dir or file \"%v\" does not exists: %v", root, err)
+				exit(1)
+			}
+		}
+	}
+
+	if optionFilesFrom.value != "" {
+		listedPaths = readFilesFrom(optionFilesFrom.value)
+	}
 
 	// Convert to absolute path if needed.
 	if optionAbsolutePath.value {
-		optionDir.value = tryGetAbsolutePath(optionDir.value)
+		for pos, root := range startingRoots {
+			startingRoots[pos] = tryGetAbsolutePath(root)
+		}
+		for pos, listedPath := range listedPaths {
+			listedPaths[pos] = tryGetAbsolutePath(listedPath)
+		}
 	}
+
+	prepareGitSelection()
+	searchRootsDescription = describeSearchRoots()
+}
+
+func describePaths(paths []string) string {
+	numDirs := 0
+	for _, p := range paths {
+		if fileInfo, err := os.Stat(p); (err == nil) && fileInfo.IsDir() {
+			numDirs++
+		}
+	}
+
+	kind := "dirs and files"
+	if numDirs == len(paths) {
+		kind = selectString(numDirs == 1, "dir", "dirs")
+	} else if numDirs == 0 {
+		kind = selectString(len(paths) == 1, "file", "files")
+	}
+	return kind + ": " + strings.Join(paths, ", ")
+}
+
+// Reads a list of paths separated by NUL characters if there are any,
+// or else by newlines.
+func readFilesFrom(fileName string) []string {
+	var data []byte
+	var err error
+	if fileName == stdinPath {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(fileName)
+	}
+	if err != nil {
+		putln("Cannot read list of files to search from \"%v\": %v", fileName, err)
+		exit(1)
+	}
+
+	separator := "\n"
+	if bytes.IndexByte(data, 0) >= 0 {
+		separator = "\x00"
+	}
+
+	paths := []string{}
+	for _, line := range strings.Split(string(data), separator) {
+		line = strings.TrimSuffix(line, "\r")
+		if line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
+}
+
+// Tells where the search happens, e.g. "dir: src" or "dirs: src, test".
+func describeSearchRoots() string {
+	var parts []string
+
+	if searchStdin {
+		parts = append(parts, "standard input")
+	}
+
+	if len(gitSelectionDirs) > 0 {
+		parts = append(parts, describeGitSelection()+describePaths(gitSelectionDirs))
+	}
+
+	if len(startingRoots) > 0 {
+		parts = append(parts, describePaths(startingRoots))
+	}
+
+	if optionFilesFrom.value != "" {
+		source := selectString(optionFilesFrom.value == stdinPath, "standard input", optionFilesFrom.value)
+		numFiles := len(listedPaths) - numGitSelectedFiles
+		parts = append(parts, fmt.Sprintf("%v %v listed in: %v",
+			numFiles, selectString(numFiles == 1, "file", "files"), source))
+	}
+
+	return strings.Join(parts, " and ")
 }
 
 func setupOutputFile() {
@@ -245,6 +377,109 @@ func finalizeOutputFile() {
 	}
 }
 
+func prepareSearcher() {
+	options := findfile.DefaultOptions()
+	options.Roots = startingRoots
+	options.Paths = listedPaths
+	// Globs with slashes match paths relative to the dir they were listed from.
+	options.PathRoots = gitSelectionDirs
+	options.MaxLevels = optionMaxLevels.value
+	options.FollowLinks = optionFollowLinks.value
+	options.DedupeFiles = optionDedupeFiles.value
+	if options.DedupeFiles && !findfile.CanDedupeFiles {
+		writeNoisyOutput("Cannot tell hard links and bind mounts apart on this system, so %v has no effect.",
+			getFirstOptionFlag(optionDedupeFiles))
+	}
+	options.ReportDuplicates = optionShowDuplicates.value
+	options.ReportBrokenLinks = optionBrokenLinks.value
+	options.OneFileSystem = optionOneFileSystem.value
+	options.ExcludeFSTypes = splitAndTrimOptionValue(optionExcludeFSTypes)
+	options.ListAll = optionListAll.value
+	options.SearchNamesOnly = optionSearchNamesOnly.value
+	options.SearchContentsOnly = optionSearchContentsOnly.value
+	options.IncludeFiles = splitAndTrimOptionValue(optionIncludeFiles)
+	options.IncludeDirs = splitAndTrimOptionValue(optionIncludeDirs)
+	options.ExcludeFiles = splitAndTrimOptionValue(optionExcludeFiles)
+	options.ExcludeDirs = splitAndTrimOptionValue(optionExcludeDirs)
+	options.Types = prepareFileTypes()
+	options.IncludeKinds = prepareFileKinds(optionIncludeTypes)
+	options.ExcludeKinds = prepareFileKinds(optionExcludeTypes)
+	options.SearchArchives = optionSearchArchives.value
+	options.MaxArchiveDepth = optionArchiveDepth.value
+	options.Decompress = optionDecompress.value
+	options.MinSize = prepareSizeOption(optionMinSize)
+	options.MaxSize = prepareSizeOption(optionMaxSize)
+	now := time.Now()
+	options.NewerThan = prepareTimeOption(optionNewer, now)
+	options.OlderThan = prepareTimeOption(optionOlder, now)
+	if newerThan := prepareFileTimeOption(optionNewerThan); newerThan.After(options.NewerThan) {
+		options.NewerThan = newerThan
+	}
+	options.SearchStrings = searchStringArgs
+	options.ExcludeStrings = splitAndTrimOptionValue(optionExcludeStrings)
+	options.IgnoreCase = optionIgnoreCase.value
+	options.WholeWord = optionWholeWord.value
+	options.Regex = optionRegex.value
+	options.InvertMatch = optionInvertMatch.value
+	options.Multiline = optionMultiline.value
+	options.HexPatterns = optionHex.value
+	options.Strings = optionStrings.value
+	options.MinStringLength = optionMinStringLength.value
+	options.Records, options.RecordRegex = prepareRecords()
+	if optionSearchBinaryFiles.value {
+		options.BinaryFiles = findfile.BinaryFilesSearch
+	} else if optionBinaryMatches.value {
+		options.BinaryFiles = findfile.BinaryFilesReport
+	}
+	options.ContextLines = optionContextLines.value
+	options.LongLines, options.MaxLineLength = prepareMaxLineLength()
+	options.Encoding = strings.ToLower(optionEncoding.value)
+	options.CountOnly = showFileNamesOnly
+	options.Jobs = optionJobs.value
+	options.UseIgnoreFiles = !optionNoIgnoreFiles.value
+	options.ReportIgnored = optionShowIgnored.value
+	if options.UseIgnoreFiles {
+		options.GlobalIgnoreFile = getGlobalIgnoreFile()
+	}
+	options.Unordered = optionUnordered.value
+	options.OnError = recordSearchError
+
+	// Always skip the output file.
+	if outputFileInfo != nil {
+		options.SkipFiles = append(options.SkipFiles, outputFileInfo)
+	}
+
+	var err error
+	searcher, err = findfile.NewSearcher(options)
+	if err != nil {
+		putln("Cannot search with the given options: %v", err)
+		exit(1)
+	}
+}
+
+// Use the same global ignore file as git.
+func getGlobalIgnoreFile() string {
+	if optionGlobalIgnoreFile.value != "" {
+		return optionGlobalIgnoreFile.value
+	}
+
+	// Ignore errors because git might not be installed.
+	output, _ := execCommand("git", "config", "--get", "core.excludesFile")
+	fileName := strings.TrimSpace(output)
+	if strings.HasPrefix(fileName, "~/") {
+		fileName = filepath.Join(userHomeDir, fileName[2:])
+	}
+	if fileName != "" {
+		return fileName
+	}
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		configDir = filepath.Join(userHomeDir, ".config")
+	}
+	return filepath.Join(configDir, "git", "ignore")
+}
+
 func setupResultsPagination() {
 	if optionMaxResults.value == 0 {
 		lastResultNumberToInclude = math.MaxInt32
@@ -262,11 +497,13 @@ func startTiming() {
 func printTiming() {
 	if optionMeasureStats.value {
 		elapsed := time.Since(searchStartTime)
-		putln("[time=%v, dirs=%v, files=%v, bytesRead=%v]",
+		stats := searcher.Stats()
+		putln("[time=%v, dirs=%v, files=%v, bytesRead=%v, specialFilesSkipped=%v]",
 			elapsed,
-			numDirsRead,
-			numFilesRead,
-			addCommasToInt(numBytesRead))
+			stats.DirsRead,
+			stats.FilesRead,
+			addCommasToInt(stats.BytesRead),
+			stats.SpecialFilesSkipped)
 	}
 }
 
@@ -278,19 +515,20 @@ func startSearching() {
 	searchType := selectString(optionInvertMatch.value, "non-matches of", "matches of")
 
 	if optionListAll.value {
-		searchDir(optionDir.value)
+		searchDir()
+		printSearchErrors()
 		return
 	}
 
-	writeNoisyOutput("%v=== Searching for %v %v in dir: %v ===",
-		osNewLine, searchType, readableSearchString, optionDir.value)
+	writeNoisyOutput("%v=== Searching for %v %v in %v ===",
+		osNewLine, searchType, readableSearchString, searchRootsDescription)
 
 	// Make up for one missing newline.
 	if !isOutputFormatStringBeginWithNewLine() || showFileNamesOnly {
 		putBlankLine()
 	}
 
-	searchDir(optionDir.value)
+	searchDir()
 
 	if currentMatchCount == 1 {
 		searchType = "match of"
@@ -299,254 +537,220 @@ func startSearching() {
 		}
 	}
 
-	writeNoisyOutput("%v=== Found %v %v %v in dir: %v ===",
-		osNewLine, currentMatchCount, searchType, readableSearchString, optionDir.value)
+	writeNoisyOutput("%v=== Found %v %v %v in %v%v ===",
+		osNewLine, currentMatchCount, searchType, readableSearchString, searchRootsDescription,
+		describeSearchErrors())
+	printSearchErrors()
 }
 
-type fileOrDirWithDepth struct {
-	fileInfo os.FileInfo
-	depth    int
-	path     string
+func recordSearchError(path string, err error) {
+	// The path is already known.
+	var pathError *fs.PathError
+	if errors.As(err, &pathError) {
+		err = pathError.Err
+	}
+	searchErrors = append(searchErrors, searchError{path, err.Error()})
 }
 
-// There is no difference in speed between this algorithm and filepath.Walk().
-// filepath.Walk() uses recursion, so this algorithm should be better.
-func searchDir(dir string) {
-	// Depth-first search using list as stack.
-	traverseList := list.New()
-	startingDirInfo, err := os.Stat(dir)
-	if err != nil {
-		putln("Cannot read starting dir \"%v\": %v", dir, err)
-		exit(1)
+func describeSearchErrors() string {
+	switch {
+	case len(searchErrors) == 0:
+		return ""
+	case optionShowErrors.value:
+		return fmt.Sprintf(" (%v unreadable)", len(searchErrors))
+	default:
+		return fmt.Sprintf(" (%v unreadable, use %v to list)", len(searchErrors), getFirstOptionFlag(optionShowErrors))
 	}
-	traverseList.PushBack(fileOrDirWithDepth{fileInfo: startingDirInfo, depth: -1, path: dir})
+}
 
-	for (traverseList.Len() > 0) && !finishSearching {
-		element := traverseList.Back()
-		traverseList.Remove(element)
-		node := element.Value.(fileOrDirWithDepth)
-		if node.depth >= 0 {
-			visitFileOrDir(node.path, node.fileInfo)
-		}
+func printSearchErrors() {
+	if !optionShowErrors.value || (len(searchErrors) == 0) {
+		return
+	}
 
-		// Check max dir depth.
-		if (optionMaxLevels.value >= 0) && (node.depth >= optionMaxLevels.value) {
-			continue
-		}
+	// Errors come in whatever order the dirs and files were read.
+	sort.SliceStable(searchErrors, func(i, j int) bool {
+		return searchErrors[i].path < searchErrors[j].path
+	})
 
-		// Get list of subdirs and apply inclusion/exclusion filters.
-		if !node.fileInfo.IsDir() {
-			continue
-		}
+	putBlankLine()
+	putln("Could not read %v %v:", len(searchErrors), selectString(len(searchErrors) == 1, "path", "paths"))
+	for _, searchError := range searchErrors {
+		putln("%v%v: %v", printIndent, searchError.path, searchError.reason)
+	}
+}
 
-		fileInfoArray, err := ioutil.ReadDir(node.path)
+// Tells whether the standard input is piped or redirected from a file,
+// rather than read from a terminal.
+func isStdinRedirected() bool {
+	fileInfo, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	mode := fileInfo.Mode()
+	return ((mode & os.ModeNamedPipe) != 0) || mode.IsRegular()
+}
+
+func searchDir() {
+	if searchStdin {
+		err := searcher.SearchReader(context.Background(), stdinFileName, os.Stdin, visitResult)
 		if err != nil {
-			continue
+			putln("%v", err)
+			exit(1)
 		}
+		if (len(startingRoots) == 0) && (len(listedPaths) == 0) {
+			return
+		}
+	}
 
-		newDepth := node.depth + 1
-		// Maintain correct order for DFS.
-		for i := len(fileInfoArray) - 1; i >= 0; i-- {
-			fileInfo := fileInfoArray[i]
-			newPath := filepath.Join(node.path, fileInfo.Name())
-
-			// Don't follow symbolic links.
-			if isLink(newPath) {
-				continue
-			}
-
-			if fileInfo.IsDir() {
-				if !shouldIncludeDirByNameFilters(fileInfo.Name()) {
-					continue
-				}
-			} else {
-				// Always skip the output file.
-				if (outputFileInfo != nil) && os.SameFile(outputFileInfo, fileInfo) {
-					continue
-				}
-
-				if !shouldIncludeFileByNameFilters(fileInfo.Name()) {
-					continue
-				}
-			}
-			traverseList.PushBack(fileOrDirWithDepth{fileInfo: fileInfo, depth: newDepth, path: newPath})
+	// Remember the results to compare with when watching for changes.
+	resultFunc := visitResult
+	if watchResults != nil {
+		resultFunc = func(result *findfile.Result) error {
+			recordWatchResult(result)
+			return visitResult(result)
 		}
 	}
-}
 
-func isLink(path string) bool {
-	fileInfo, err := os.Lstat(path)
+	err := searcher.Search(context.Background(), resultFunc)
 	if err != nil {
-		return true
+		putln("%v", err)
+		exit(1)
 	}
-	return (fileInfo.Mode() & os.ModeSymlink) == os.ModeSymlink
 }
 
-func visitFileOrDir(path string, fileInfo os.FileInfo) {
-	currentFilePath = path
-	isDir := fileInfo.IsDir()
+func visitResult(result *findfile.Result) error {
+	currentResult = result
 
-	if isDir {
-		numDirsRead++
-	} else {
-		numFilesRead++
-	}
-
-	if optionListAll.value {
+	switch result.Kind {
+	case findfile.ResultEntry:
 		// Prevent any unwanted string escapes.
-		puts(path)
+		puts(result.Path)
 		putBlankLine()
-		return
-	}
+		return nil
 
-	if !optionSearchContentsOnly.value {
-		matched := searchPathName(isDir)
+	case findfile.ResultName:
+		return searchPathName(result)
 
-		// Don't double-print the same filename.
-		// The side effect of this is that once the dir or file name matches,
-		// we will not show the match count within the file content:
-		// e.g. ff -2 txt
-		if showFileNamesOnly && matched {
-			return
-		}
-	}
+	case findfile.ResultFile:
+		return searchFileContentsForFileNameOnly(result)
 
-	if !isDir && !optionSearchNamesOnly.value {
-		searchFileContents()
-	}
-}
+	case findfile.ResultIgnored:
+		putln("Ignored %v %v (%v)", selectString(result.IsDir, "dir", "file"), result.Path, result.Text)
+		return nil
 
-/**************************************************************************/
+	case findfile.ResultBrokenLink:
+		putln("Broken link %v -> %v", result.Path, result.Text)
+		return nil
 
-// Searching file and dir names.
+	case findfile.ResultBinaryFile:
+		return searchBinaryFileContents(result)
 
-func searchPathName(isDir bool) bool {
-	baseName := filepath.Base(currentFilePath)
+	case findfile.ResultBytes:
+		return searchFileBytes(result)
 
-	// Check for match.
-	checkLineMatchFullInfo(baseName, &currentLineIntArray)
+	case findfile.ResultDuplicate:
+		putln("Duplicate %v %v of %v", selectString(result.IsDir, "dir", "file"), result.Path, result.Text)
+		return nil
 
-	if currentLineMatchIndexInfo.matched == optionInvertMatch.value {
-		return false
+	case findfile.ResultPrunedMount:
+		writeNoisyOutput("Skipped mount point %v (%v)", result.Path, result.Text)
+		return nil
+
+	default:
+		return searchFileContents(result)
 	}
+}
+
+/**************************************************************************/
 
+// Searching file and dir names.
+
+func searchPathName(result *findfile.Result) error {
 	currentMatchCount++
 	currentNumResults++
 
 	// Print result.
 	if currentNumResults >= optionFirstResult.value {
-		writePathNameOutputLine(baseName, "(skip content)", isDir)
+		writePathNameOutputLine(result.Text, "(skip content)", result.IsDir)
 		if currentNumResults >= lastResultNumberToInclude {
-			finishSearching = true
+			return findfile.StopSearch
 		}
 	}
-	return true
+	return nil
 }
 
 /**************************************************************************/
 
 // Searching within files.
 
-func searchFileContents() {
-	// Open file for reading.
-	fileHandle, err := os.Open(currentFilePath)
-	if err != nil {
-		// This output is usually unnecessary, but cannot be sure.
-		writeNoisyOutput("Cannot read file %v: %v", currentFilePath, err)
-		return
-	}
-	defer fileHandle.Close()
-
-	setFileForScanning(fileHandle)
-
-	// If file is empty, return.
-	if !hasNextLineInFileOrCache() {
-		return
-	}
-
-	// Only check first line for binary characters.
-	currentLineText = getNextLineFromFileOrCache()
-	if !optionSearchBinaryFiles.value && hasControlCharacters(currentLineText) {
-		return
-	}
+func searchFileContents(result *findfile.Result) error {
+	currentMatchCount++
+	currentNumResults++
 
-	// If we want to show filenames only, then we do something special.
-	if showFileNamesOnly {
-		searchFileContentsForFileNameOnly(currentLineText)
-		return
+	if currentNumResults < optionFirstResult.value {
+		return nil
 	}
 
-	// Normal search through each line in the file.
-	for currentLineNumber = 1; ; currentLineNumber++ {
-		if isLineMatchingWithFullInfo(currentLineText, &currentLineIntArray) {
-			currentMatchCount++
-			currentNumResults++
+	currentLineIntArray = insertMatchDecorations(currentLineIntArray[:0], result.Text, result.Spans)
 
-			if currentNumResults >= optionFirstResult.value {
+	// Format matching line.
+	transformOutputLine()
 
-				// This optimization if-statement is to avoid initializing currentLineIntArray twice.
-				// A bit hard to understand, but cannot think of a better approach right now.
-				if needMatchDecorations {
-					currentLineIntArray = insertMatchDecorations(currentLineIntArray[:0], currentLineText)
-				} else if len(currentLineIntArray) == 0 {
-					currentLineIntArray = appendStringToIntArray(currentLineIntArray, currentLineText)
-				}
+	// Output matching line.
+	writeFormattedOutputLine()
 
-				// Format matching line.
-				transformOutputLine()
+	// If we already reached max results, then stop searching.
+	if currentNumResults >= lastResultNumberToInclude {
+		return findfile.StopSearch
+	}
+	return nil
+}
 
-				// Output matching line.
-				writeFormattedOutputLine()
+func searchBinaryFileContents(result *findfile.Result) error {
+	currentMatchCount++
+	currentNumResults++
 
-				// If we already reached max results, then stop searching.
-				if currentNumResults >= lastResultNumberToInclude {
-					finishSearching = true
-					return
-				}
-			}
-		}
-		if !hasNextLineInFileOrCache() {
-			return
+	if currentNumResults >= optionFirstResult.value {
+		putln("Binary file %v matches at byte offset %v", result.Path, result.Offset)
+		if currentNumResults >= lastResultNumberToInclude {
+			return findfile.StopSearch
 		}
-		pushToPreContextLines(currentLineText)
-		currentLineText = getNextLineFromFileOrCache()
 	}
+	return nil
 }
 
-// We need the first line since it was read for determining whether the file is binary or not.
-func searchFileContentsForFileNameOnly(firstLine string) {
-	line := firstLine
-	numMatches := 0
+func searchFileBytes(result *findfile.Result) error {
+	currentMatchCount++
+	currentNumResults++
 
-	for {
-		if isLineMatching(line, &currentLineIntArray) {
-			// We could have just stopped after the first match, but printing the
-			// total number of matches provides a better user experience.
-			numMatches++
-		}
+	if currentNumResults < optionFirstResult.value {
+		return nil
+	}
 
-		if !hasNextLineInFileOrCache() {
-			break
-		}
+	// The bytes around the match start a number of bytes before it.
+	textOffset := result.Offset - int64(result.Spans[0].Begin)
+	currentLineIntArray = appendHexdump(currentLineIntArray[:0], []byte(result.Text), textOffset, result.Spans)
+	writeFormattedOutputLine()
 
-		line = getNextLineFromFileOrCache()
+	if currentNumResults >= lastResultNumberToInclude {
+		return findfile.StopSearch
 	}
+	return nil
+}
 
-	// Return if not matching.
-	hadMatch := (numMatches > 0)
-	if hadMatch == optionInvertMatch.value {
-		return
-	}
-	currentMatchCount += numMatches
+func searchFileContentsForFileNameOnly(result *findfile.Result) error {
+	currentMatchCount += result.NumMatches
 	currentNumResults++
 
 	// Print result.
 	if currentNumResults >= optionFirstResult.value {
-		writePathNameOutputLine("", strconv.Itoa(numMatches), false)
+		writePathNameOutputLine("", strconv.Itoa(result.NumMatches), false)
 		if currentNumResults >= lastResultNumberToInclude {
-			finishSearching = true
+			return findfile.StopSearch
 		}
 	}
+	return nil
 }
 
 /**************************************************************************/
@@ -580,6 +784,10 @@ func main() {
 	validateArguments()
 	performArgumentActions()
 	performSearch()
+
+	if len(searchErrors) > 0 {
+		cleanUpAndExit(incompleteSearchExitCode)
+	}
 	cleanUpAndExit(0)
 }
 
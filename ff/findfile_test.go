@@ -0,0 +1,63 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+/**************************************************************************/
+
+// Unreadable dirs and files.
+
+func TestSearchErrors(t *testing.T) {
+	defer func() {
+		searchErrors, optionShowErrors.value = nil, false
+	}()
+
+	searchErrors = nil
+	if got := describeSearchErrors(); got != "" {
+		t.Errorf("got %q with no errors", got)
+	}
+
+	// The path is only given once.
+	recordSearchError("locked", &fs.PathError{Op: "open", Path: "locked", Err: fs.ErrPermission})
+	want := []searchError{{"locked", fs.ErrPermission.Error()}}
+	if !reflect.DeepEqual(searchErrors, want) {
+		t.Errorf("got %q, want %q", searchErrors, want)
+	}
+
+	if got, want := describeSearchErrors(), " (1 unreadable, use -se to list)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	optionShowErrors.value = true
+	if got, want := describeSearchErrors(), " (1 unreadable)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+/**************************************************************************/
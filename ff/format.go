@@ -25,6 +25,7 @@ SOFTWARE.
 package main
 
 import (
+	"ff/findfile"
 	"fmt"
 	"sort"
 	"strconv"
@@ -39,8 +40,8 @@ var (
 	needColoring                       bool
 	needMatchDecorations               bool
 	showFileNamesOnly                  bool
+	isSearchingRecords                 bool
 	currentMatchCount                  int
-	currentLineNumber                  int
 	currentLineIntArray                = make([]int, 0, 1000)
 	beginColorIndexes                  = make(sort.IntSlice, 0, 20)
 	endColorIndexes                    = make(sort.IntSlice, 0, 20)
@@ -78,6 +79,10 @@ func prepareOutputFormat() {
 		outputFormatString = outputFormat0
 	} else if optionFormat1ShowFileNamesAndLines.value {
 		outputFormatString = outputFormat1
+	} else if optionMultiline.value && !optionFormat.isGiven {
+		outputFormatString = outputFormatMultiline
+	} else if (optionHex.value || optionStrings.value) && !optionFormat.isGiven {
+		outputFormatString = outputFormatOffset
 	} else if optionFormat.value != "" {
 		outputFormatString = optionFormat.value
 	}
@@ -117,19 +122,36 @@ func prepareOutputFormat() {
 			})
 		case 'p':
 			funcs = append(funcs, func() {
-				puts(currentFilePath)
+				puts(currentResult.Path)
 			})
 		case 'l':
 			funcs = append(funcs, func() {
-				puts(strconv.Itoa(currentLineNumber))
+				// Matches of byte patterns and strings have no lines.
+				if (currentResult.Kind == findfile.ResultBytes) || optionStrings.value {
+					puts(strconv.FormatInt(currentResult.Offset, 10))
+				} else {
+					puts(strconv.Itoa(currentResult.LineNumber))
+				}
 			})
 		case 'c':
 			funcs = append(funcs, func() {
-				puts(strconv.Itoa(currentLineMatchIndexInfo.minIndex + 1))
+				puts(strconv.Itoa(currentResult.Column))
+			})
+		case 'L':
+			funcs = append(funcs, func() {
+				puts(strconv.Itoa(currentResult.EndLineNumber))
+			})
+		case 'C':
+			funcs = append(funcs, func() {
+				puts(strconv.Itoa(currentResult.EndColumn))
 			})
 		case 's':
 			funcs = append(funcs, func() {
-				putIntArrayWithColors(currentLineIntArray)
+				if needColoring {
+					putIntArrayWithColors(currentLineIntArray)
+				} else {
+					putIntArrayWithoutColors(currentLineIntArray)
+				}
 			})
 		case 'n':
 			funcs = append(funcs, func() {
@@ -166,19 +188,21 @@ func writeFormattedOutputLine() {
 
 func writePathNameOutputLine(baseName, numMatchesAsString string, isDir bool) {
 	if optionFormat3ShowFileNamesOnly.value {
-		puts(currentFilePath)
+		puts(currentResult.Path)
 		putBlankLine()
 	} else if optionFormat2ShowFileNamesAndCounts.value {
-		putln("%15v : %v", numMatchesAsString, currentFilePath)
+		if (currentResult.Encoding == "") || (currentResult.Encoding == findfile.EncodingUTF8) {
+			putln("%15v : %v", numMatchesAsString, currentResult.Path)
+		} else {
+			putln("%15v : %v (%v)", numMatchesAsString, currentResult.Path, currentResult.Encoding)
+		}
 	} else {
 		if baseName == "" {
 			panic("Impossible case in show filename only condition")
 		}
-		currentLineNumber = 0
-		currentLineMatchIndexInfo.minIndex = -1
 		fileOrDir := selectString(isDir, "dir", "file")
 		line := fmt.Sprintf("%v - %v name %v", baseName, fileOrDir, currentMatchesPhrase)
-		currentLineIntArray = insertMatchDecorations(currentLineIntArray[:0], line)
+		currentLineIntArray = insertMatchDecorations(currentLineIntArray[:0], line, currentResult.Spans)
 		writeFormattedOutputLine()
 	}
 }
@@ -187,20 +211,21 @@ func writePathNameOutputLine(baseName, numMatchesAsString string, isDir bool) {
 
 // Output coloring.
 
-func insertMatchDecorations(array []int, line string) []int {
+// The match spans are byte offsets into line.
+func insertMatchDecorations(array []int, line string, spans []findfile.Span) []int {
 	if !needMatchDecorations {
 		return appendStringToIntArray(array, line)
 	}
 
 	// Get marker indexes.
-	numMatches := len(currentLineMatchIndexInfo.matchIndexes)
+	numMatches := len(spans)
 
 	beginColorIndexes = beginColorIndexes[:0]
 	endColorIndexes = endColorIndexes[:0]
 
-	for _, span := range currentLineMatchIndexInfo.matchIndexes {
-		beginColorIndexes = append(beginColorIndexes, span.beginIndex)
-		endColorIndexes = append(endColorIndexes, span.endIndex)
+	for _, span := range spans {
+		beginColorIndexes = append(beginColorIndexes, span.Begin)
+		endColorIndexes = append(endColorIndexes, span.End)
 	}
 
 	beginColorIndexes.Sort()
@@ -250,10 +275,10 @@ func insertMatchDecorations(array []int, line string) []int {
 	return array
 }
 
+// Color markers are added even when not coloring, since they tell where the
+// matches are when fitting the line to the context columns.
 func appendMatchDecorationsBegin(array []int) []int {
-	if needColoring {
-		array = append(array, color1RuneBegin)
-	}
+	array = append(array, color1RuneBegin)
 	if optionShowBrackets.value {
 		array = append(array, '[')
 	}
@@ -264,10 +289,7 @@ func appendMatchDecorationsEnd(array []int) []int {
 	if optionShowBrackets.value {
 		array = append(array, ']')
 	}
-	if needColoring {
-		array = append(array, colorRuneEnd)
-	}
-	return array
+	return append(array, colorRuneEnd)
 }
 
 /**************************************************************************/
@@ -279,6 +301,11 @@ func isOutputFormatStringBeginWithNewLine() bool {
 }
 
 func transformOutputLine() {
+	if optionMultiline.value || isSearchingRecords {
+		transformMultilineOutputLine()
+		return
+	}
+
 	// Context columns are calculated based on the original line.
 	currentLineIntArray = transformSingleOutputLine(currentLineIntArray, true)
 
@@ -291,52 +318,111 @@ func transformOutputLine() {
 	matchingLineIntArrayTempBuffer = append(matchingLineIntArrayTempBuffer[:0], currentLineIntArray...)
 	currentLineIntArray = currentLineIntArray[:0]
 
+	lineNumber := currentResult.LineNumber
+
 	// Add pre-context lines.
-	for i := optionContextLines.value - 1; i >= 0; i-- {
-		contextLine := getContextLineByDelta(-i - 1)
-		if contextLine.lineAsStringIsValid {
-			j := i + 1
-			currentLineIntArray = appendStringToIntArray(
-				currentLineIntArray,
-				fmt.Sprintf("%v:-%v: ", currentLineNumber-j, j))
-
-			contextLineIntArrayTempBuffer = appendStringToIntArray(contextLineIntArrayTempBuffer[:0], contextLine.lineAsString)
-			contextLineIntArrayTempBuffer = transformSingleOutputLine(contextLineIntArrayTempBuffer, false)
-
-			currentLineIntArray = append(currentLineIntArray, contextLineIntArrayTempBuffer...)
-			currentLineIntArray = appendStringToIntArray(currentLineIntArray, osNewLine)
-		}
+	for _, contextLine := range currentResult.Before {
+		appendContextLine(fmt.Sprintf("%v:-%v: ", contextLine.Number, lineNumber-contextLine.Number), contextLine.Text)
 	}
 
 	// Add the matching line itself.
 	currentLineIntArray = appendStringToIntArray(
 		currentLineIntArray,
-		fmt.Sprintf("%v: 0: ", currentLineNumber))
+		fmt.Sprintf("%v: 0: ", lineNumber))
 	currentLineIntArray = append(currentLineIntArray, matchingLineIntArrayTempBuffer...)
 	currentLineIntArray = appendStringToIntArray(currentLineIntArray, osNewLine)
 
-	// Pre-read post-context lines from the file if needed.
-	fillPostContextLines()
-
 	// Add post-context lines.
-	for i := 0; i < optionContextLines.value; i++ {
-		contextLine := getContextLineByDelta(i + 1)
-		if !contextLine.lineAsStringIsValid {
-			break
+	for _, contextLine := range currentResult.After {
+		appendContextLine(fmt.Sprintf("%v:+%v: ", contextLine.Number, contextLine.Number-lineNumber), contextLine.Text)
+	}
+}
+
+// Each line covered by a multiline match, or of a matching record, is shown
+// with its part of the match, and fitted to the context columns on its own.
+func transformMultilineOutputLine() {
+	currentLineIntArray = currentLineIntArray[:0]
+	lineNumber := currentResult.LineNumber
+	endLineNumber := currentResult.EndLineNumber
+	hasContextLines := (optionContextLines.value > 0)
+
+	lineBegin := 0
+	for pos, line := range strings.Split(currentResult.Text, "\n") {
+		lineEnd := lineBegin + len(line)
+		var spans []findfile.Span
+		for _, span := range currentResult.Spans {
+			if (span.Begin >= lineEnd) || (span.End <= lineBegin) {
+				continue
+			}
+			clipped := findfile.Span{Begin: 0, End: len(line)}
+			if span.Begin > lineBegin {
+				clipped.Begin = span.Begin - lineBegin
+			}
+			if span.End < lineEnd {
+				clipped.End = span.End - lineBegin
+			}
+			spans = append(spans, clipped)
+		}
+		line = strings.TrimSuffix(line, "\r")
+
+		// A line covered only by the line end of the match is shown from
+		// its start, like a line without matches.
+		isDecorating := needMatchDecorations
+		needMatchDecorations = isDecorating && (len(spans) > 0)
+		matchingLineIntArrayTempBuffer = insertMatchDecorations(matchingLineIntArrayTempBuffer[:0], line, spans)
+		matchingLineIntArrayTempBuffer = transformSingleOutputLine(matchingLineIntArrayTempBuffer, true)
+		needMatchDecorations = isDecorating
+
+		// Context lines are fitted to the columns of the nearest covered line.
+		if pos == 0 {
+			for pos, contextLine := range currentResult.Before {
+				distance := lineNumber - contextLine.Number
+				if isSearchingRecords {
+					distance = len(currentResult.Before) - pos
+				}
+				appendContextRecord(contextLine, fmt.Sprintf("-%v", distance))
+			}
 		}
-		j := i + 1
-		currentLineIntArray = appendStringToIntArray(
-			currentLineIntArray,
-			fmt.Sprintf("%v:+%v: ", currentLineNumber+j, j))
 
-		contextLineIntArrayTempBuffer = appendStringToIntArray(contextLineIntArrayTempBuffer[:0], contextLine.lineAsString)
-		contextLineIntArrayTempBuffer = transformSingleOutputLine(contextLineIntArrayTempBuffer, false)
+		if hasContextLines {
+			currentLineIntArray = appendStringToIntArray(currentLineIntArray, fmt.Sprintf("%v: 0: ", lineNumber+pos))
+		} else if pos > 0 {
+			currentLineIntArray = appendStringToIntArray(currentLineIntArray, osNewLine)
+		}
+		currentLineIntArray = append(currentLineIntArray, matchingLineIntArrayTempBuffer...)
+		if hasContextLines {
+			currentLineIntArray = appendStringToIntArray(currentLineIntArray, osNewLine)
+		}
+		lineBegin = lineEnd + 1
+	}
+
+	for pos, contextLine := range currentResult.After {
+		distance := contextLine.Number - endLineNumber
+		if isSearchingRecords {
+			distance = pos + 1
+		}
+		appendContextRecord(contextLine, fmt.Sprintf("+%v", distance))
+	}
+}
 
-		currentLineIntArray = append(currentLineIntArray, contextLineIntArrayTempBuffer...)
-		currentLineIntArray = appendStringToIntArray(currentLineIntArray, osNewLine)
+// Context records are shown a line at a time, each with its line number
+// and the distance in records.
+func appendContextRecord(record findfile.Line, distance string) {
+	for pos, line := range strings.Split(record.Text, "\n") {
+		appendContextLine(fmt.Sprintf("%v:%v: ", record.Number+pos, distance), strings.TrimSuffix(line, "\r"))
 	}
 }
 
+func appendContextLine(prefix, text string) {
+	currentLineIntArray = appendStringToIntArray(currentLineIntArray, prefix)
+
+	contextLineIntArrayTempBuffer = appendStringToIntArray(contextLineIntArrayTempBuffer[:0], text)
+	contextLineIntArrayTempBuffer = transformSingleOutputLine(contextLineIntArrayTempBuffer, false)
+
+	currentLineIntArray = append(currentLineIntArray, contextLineIntArrayTempBuffer...)
+	currentLineIntArray = appendStringToIntArray(currentLineIntArray, osNewLine)
+}
+
 func transformSingleOutputLine(line []int, needCalculateContextColumns bool) []int {
 	// The ordering here is important:
 	// 1. Tabs will expand before control characters are removed.
@@ -666,8 +752,7 @@ func replaceControlCharacters(array []int) []int {
 		return array
 	}
 
-	newArray := make([]int, 0, len(array))
-	copy(newArray, array)
+	newArray := append([]int(nil), array...)
 	array = array[:0]
 
 	for _, char := range newArray {
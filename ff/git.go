@@ -0,0 +1,168 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/**************************************************************************/
+
+// Selecting files with git.
+
+var (
+	// Starting dirs whose files are listed by git instead of being walked.
+	gitSelectionDirs []string
+
+	// Number of files listed by git, which come after the files from
+	// the files-from option in listedPaths.
+	numGitSelectedFiles int
+
+	// Git's arguments for listing each kind of file, relative to the
+	// current dir. Deleted files are left out since they cannot be searched,
+	// which for tracked files takes listing them separately.
+	gitFileListArgs = map[string][]string{
+		"tracked":   {"ls-files", "-z"},
+		"modified":  {"diff", "--name-only", "-z", "--relative", "--diff-filter=d", "HEAD"},
+		"staged":    {"diff", "--name-only", "-z", "--relative", "--diff-filter=d", "--cached"},
+		"untracked": {"ls-files", "-z", "--others", "--exclude-standard"},
+	}
+)
+
+func isGitSelectionGiven() bool {
+	return (optionGit.value != "") || (optionGitSince.value != "")
+}
+
+// Replaces walking the starting dirs with searching the files that git
+// lists in them.
+func prepareGitSelection() {
+	if !isGitSelectionGiven() {
+		return
+	}
+
+	kinds := splitAndTrimOptionValue(optionGit)
+	for _, kind := range kinds {
+		if gitFileListArgs[kind] == nil {
+			putln("Bad value %v for %v: expecting tracked, modified, staged or untracked.", kind, optionGit.flags)
+			exit(1)
+		}
+	}
+
+	var roots []string
+	for _, root := range startingRoots {
+		if fileInfo, err := os.Stat(root); (err == nil) && fileInfo.IsDir() {
+			gitSelectionDirs = append(gitSelectionDirs, root)
+		} else {
+			roots = append(roots, root)
+		}
+	}
+	startingRoots = roots
+
+	// Search nothing rather than the current dir when git lists no files.
+	if listedPaths == nil {
+		listedPaths = []string{}
+	}
+
+	for _, dir := range gitSelectionDirs {
+		seen := make(map[string]bool)
+		var paths []string
+		addPaths := func(output string) {
+			for _, p := range strings.Split(output, "\x00") {
+				if (p != "") && !seen[p] {
+					seen[p] = true
+					paths = append(paths, p)
+				}
+			}
+		}
+
+		for _, kind := range kinds {
+			if kind == "tracked" {
+				// Files deleted from the work tree stay in the index until the
+				// deletion is staged.
+				for _, p := range strings.Split(runGit(dir, "ls-files", "-z", "--deleted"), "\x00") {
+					seen[p] = true
+				}
+			}
+			addPaths(runGit(dir, gitFileListArgs[kind]...))
+		}
+
+		if optionGitSince.value != "" {
+			// Changes made on the current branch, committed or not.
+			mergeBase := strings.TrimSpace(runGit(dir, "merge-base", optionGitSince.value, "HEAD"))
+			addPaths(runGit(dir, "diff", "--name-only", "-z", "--relative", "--diff-filter=d", mergeBase))
+		}
+
+		sortPathsInWalkOrder(paths)
+		for _, p := range paths {
+			listedPaths = append(listedPaths, filepath.Join(dir, filepath.FromSlash(p)))
+			numGitSelectedFiles++
+		}
+	}
+}
+
+func runGit(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		putln("Cannot list files with git in dir \"%v\": %v %v", dir, err, strings.TrimSpace(stderr.String()))
+		exit(1)
+	}
+	return stdout.String()
+}
+
+// Sorts slash-separated paths the way dirs are walked, where the files in
+// "a/" come before "a.txt".
+func sortPathsInWalkOrder(paths []string) {
+	sort.Slice(paths, func(i, j int) bool {
+		a := strings.Split(paths[i], "/")
+		b := strings.Split(paths[j], "/")
+		for k := 0; (k < len(a)) && (k < len(b)); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+func describeGitSelection() string {
+	var parts []string
+	if optionGit.value != "" {
+		parts = append(parts, strings.Join(splitAndTrimOptionValue(optionGit), " or ")+" files")
+	}
+	if optionGitSince.value != "" {
+		parts = append(parts, "files changed since "+optionGitSince.value)
+	}
+	return "git " + strings.Join(parts, " or ") + " in "
+}
+
+/**************************************************************************/
@@ -0,0 +1,136 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+/**************************************************************************/
+
+// Selecting files with git.
+
+func runGitForTest(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v %s", args, err, output)
+	}
+}
+
+func writeFileForTest(t *testing.T, dir, name, text string) {
+	t.Helper()
+	name = filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Returns the paths selected in the dir, relative to it.
+func selectWithGitForTest(t *testing.T, dir, kinds, since string) []string {
+	t.Helper()
+	optionGit.value, optionGitSince.value = kinds, since
+	startingRoots = []string{dir}
+	listedPaths, gitSelectionDirs, numGitSelectedFiles = nil, nil, 0
+	defer func() {
+		optionGit.value, optionGitSince.value = "", ""
+		startingRoots, listedPaths, gitSelectionDirs, numGitSelectedFiles = nil, nil, nil, 0
+	}()
+
+	prepareGitSelection()
+	paths := []string{}
+	for _, p := range listedPaths {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	return paths
+}
+
+func TestGitSelection(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	dir := t.TempDir()
+	runGitForTest(t, dir, "init", "-q", "-b", "main")
+	for _, name := range []string{"a.txt", "b/c.txt", "b/d.txt", "b.txt", "gone.txt", "staged.txt"} {
+		writeFileForTest(t, dir, name, "text\n")
+	}
+	writeFileForTest(t, dir, ".gitignore", "*.log\n")
+	runGitForTest(t, dir, "add", ".")
+	runGitForTest(t, dir, "commit", "-q", "-m", "first")
+	runGitForTest(t, dir, "checkout", "-q", "-b", "topic")
+	writeFileForTest(t, dir, "b/c.txt", "committed change\n")
+	runGitForTest(t, dir, "commit", "-q", "-a", "-m", "second")
+
+	writeFileForTest(t, dir, "a.txt", "change\n")
+	writeFileForTest(t, dir, "staged.txt", "staged change\n")
+	runGitForTest(t, dir, "add", "staged.txt")
+	writeFileForTest(t, dir, "new.txt", "untracked\n")
+	writeFileForTest(t, dir, "debug.log", "ignored\n")
+	if err := os.Remove(filepath.Join(dir, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kinds string
+		since string
+		want  []string
+	}{
+		{"tracked", "", []string{".gitignore", "a.txt", "b/c.txt", "b/d.txt", "b.txt", "staged.txt"}},
+		{"modified", "", []string{"a.txt", "staged.txt"}},
+		{"staged", "", []string{"staged.txt"}},
+		{"untracked", "", []string{"new.txt"}},
+		{"staged;untracked", "", []string{"new.txt", "staged.txt"}},
+		{"", "main", []string{"a.txt", "b/c.txt", "staged.txt"}},
+	}
+	for _, test := range tests {
+		got := selectWithGitForTest(t, dir, test.kinds, test.since)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("kinds %q since %q: got %q, want %q", test.kinds, test.since, got, test.want)
+		}
+	}
+}
+
+func TestSortPathsInWalkOrder(t *testing.T) {
+	paths := []string{"b.txt", "a.txt", "a/z.txt", "a/b/c.txt", "a-b.txt"}
+	sortPathsInWalkOrder(paths)
+	want := []string{"a/b/c.txt", "a/z.txt", "a-b.txt", "a.txt", "b.txt"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %q, want %q", paths, want)
+	}
+}
+
+/**************************************************************************/
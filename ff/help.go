@@ -27,6 +27,7 @@ package main
 import (
 	"bytes"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -105,6 +106,34 @@ func printListOfOptions() {
 	putBlankLine()
 }
 
+func printListOfTypes() {
+	kindsByName := getFileKindsByName()
+	names := make([]string, 0, len(kindsByName))
+	for name := range kindsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	putBlankLine()
+	putln("List of file types for %v and %v:", optionIncludeTypes.flags, optionExcludeTypes.flags)
+	putBlankLine()
+
+	rows := make([][]string, len(names))
+	for pos, name := range names {
+		kind := kindsByName[name]
+		patterns := strings.Join(kind.Globs, " ")
+		for _, interpreter := range kind.Interpreters {
+			patterns += " #!" + interpreter
+		}
+		rows[pos] = []string{name, strings.TrimSpace(patterns)}
+	}
+	printNeatColumns(rows, 3, 2)
+	putBlankLine()
+
+	putln("Use \"%v %v\" to define more types.", programName, optionDefineTypes.flags)
+	putBlankLine()
+}
+
 func optionsToFlagsArray(options []anyOption) [][]string {
 	flagsArray := make([][]string, len(options))
 	for pos, option := range options {
@@ -241,9 +270,11 @@ Output format string:
 
 ` + ddIndent + `%i :  result number, 1-indexed` + mdLineBreak + `
 ` + ddIndent + `%p :  file path` + mdLineBreak + `
-` + ddIndent + `%l :  line number, 1-indexed` + mdLineBreak + `
+` + ddIndent + `%l :  line number, 1-indexed, or byte offset with hex patterns and strings` + mdLineBreak + `
 ` + ddIndent + `%c :  column number, 1-indexed` + mdLineBreak + `
-` + ddIndent + `%s :  full line` + mdLineBreak + `
+` + ddIndent + `%L :  line number of the end of the match` + mdLineBreak + `
+` + ddIndent + `%C :  column number of the end of the match` + mdLineBreak + `
+` + ddIndent + `%s :  full line, or all the lines covered by a multiline match` + mdLineBreak + `
 ` + ddIndent + `%% :  percent sign` + mdLineBreak + `
 ` + ddIndent + `%n :  newline` + mdLineBreak + `
 
@@ -0,0 +1,106 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"ff/findfile"
+	"fmt"
+)
+
+/**************************************************************************/
+
+// Hexdump output.
+
+// Appends a hexdump of data, which starts at the given byte offset, with a
+// row per line like hexdump -C, and the matching bytes colored in both the
+// hex and the text columns.
+func appendHexdump(array []int, data []byte, offset int64, spans []findfile.Span) []int {
+	isMatching := func(pos int) bool {
+		for _, span := range spans {
+			if (pos >= span.Begin) && (pos < span.End) {
+				return true
+			}
+		}
+		return false
+	}
+
+	rowOffset := offset - offset%findfile.HexRowSize
+	for ; rowOffset < offset+int64(len(data)); rowOffset += findfile.HexRowSize {
+		if rowOffset > offset-offset%findfile.HexRowSize {
+			array = appendStringToIntArray(array, osNewLine)
+		}
+		array = appendStringToIntArray(array, fmt.Sprintf("%08x  ", rowOffset))
+
+		// Hex column.
+		first := int(rowOffset - offset)
+		isInMatch := false
+		for i := 0; i < findfile.HexRowSize; i++ {
+			pos := first + i
+			if (pos < 0) || (pos >= len(data)) {
+				array = appendStringToIntArray(array, "   ")
+			} else {
+				if isMatching(pos) && !isInMatch {
+					array = append(array, color1RuneBegin)
+					isInMatch = true
+				}
+				array = appendStringToIntArray(array, fmt.Sprintf("%02x", data[pos]))
+				if isInMatch && (!isMatching(pos+1) || (i == findfile.HexRowSize-1)) {
+					array = append(array, colorRuneEnd)
+					isInMatch = false
+				}
+				array = append(array, ' ')
+			}
+			if i == findfile.HexRowSize/2-1 {
+				array = append(array, ' ')
+			}
+		}
+
+		// Text column.
+		array = appendStringToIntArray(array, " |")
+		for i := 0; i < findfile.HexRowSize; i++ {
+			pos := first + i
+			if (pos < 0) || (pos >= len(data)) {
+				continue
+			}
+			if isMatching(pos) && !isInMatch {
+				array = append(array, color1RuneBegin)
+				isInMatch = true
+			}
+			char := int(data[pos])
+			if (char < ' ') || (char > '~') {
+				char = '.'
+			}
+			array = append(array, char)
+			if isInMatch && (!isMatching(pos+1) || (i == findfile.HexRowSize-1)) {
+				array = append(array, colorRuneEnd)
+				isInMatch = false
+			}
+		}
+		array = append(array, '|')
+	}
+	return array
+}
+
+/**************************************************************************/
@@ -0,0 +1,368 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"ff/findfile"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/**************************************************************************/
+
+// Watching for changes.
+
+// How often to look for changes when inotify is not available.
+const watchPollInterval = 2 * time.Second
+
+// How long to wait for more changes before searching again.
+const watchSettleDelay = 200 * time.Millisecond
+
+// Blocks until something may have changed in the watched dirs and files,
+// returning the dirs whose entries may have changed, or true if that is
+// not known and everything must be listed again.
+type changeNotifier interface {
+	watch(paths []string)
+	wait() ([]string, bool)
+}
+
+type pollingNotifier struct{}
+
+func (pollingNotifier) watch(paths []string) {}
+
+func (pollingNotifier) wait() ([]string, bool) {
+	time.Sleep(watchPollInterval)
+	return nil, true
+}
+
+type watchedFile struct {
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+// A result as remembered from the previous pass, for telling what
+// appeared or disappeared.
+type watchedResult struct {
+	key         string
+	description string
+}
+
+var (
+	// Results of the current pass by path, or nil when not watching.
+	watchResults map[string][]watchedResult
+)
+
+func newWatchedResult(result *findfile.Result) (watchedResult, bool) {
+	var description string
+	switch result.Kind {
+	case findfile.ResultLine:
+		description = fmt.Sprintf("%v line %v: %v", result.Path, result.LineNumber, result.Text)
+	case findfile.ResultBytes:
+		description = fmt.Sprintf("%v offset %v", result.Path, result.Offset)
+	case findfile.ResultName, findfile.ResultFile, findfile.ResultBinaryFile:
+		description = result.Path
+	default:
+		return watchedResult{}, false
+	}
+
+	// Line numbers are left out so that lines moved around are not reported.
+	key := fmt.Sprintf("%v\x00%v\x00%v\x00%v", result.Path, result.Kind, result.Text, result.NumMatches)
+	return watchedResult{key, description}, true
+}
+
+func recordWatchResult(result *findfile.Result) {
+	if r, ok := newWatchedResult(result); ok {
+		watchResults[result.Path] = append(watchResults[result.Path], r)
+	}
+}
+
+func setupWatching() {
+	if optionWatch.value {
+		watchResults = make(map[string][]watchedResult)
+	}
+}
+
+// Searches again the files that change, and prints the results that
+// appeared or disappeared since the previous pass. Never returns.
+func watchForChanges() {
+	if searchStdin {
+		putln("Cannot watch the standard input for changes.")
+		exit(1)
+	}
+
+	// List the dirs and files searched, with the same filters.
+	options := searcher.Options()
+	options.ListAll = true
+	options.Unordered = true
+	options.SearchArchives = false
+	options.ReportIgnored = false
+	options.ReportBrokenLinks = false
+	options.OnError = nil
+	wantsDirs := (len(options.Types) == 0)
+	for _, fileType := range options.Types {
+		wantsDirs = wantsDirs || (fileType == findfile.TypeDir)
+	}
+	if !wantsDirs {
+		// Dirs are needed for watching, but their names are not searched.
+		options.Types = append(options.Types, findfile.TypeDir)
+	}
+	lister, err := findfile.NewSearcher(options)
+	if err != nil {
+		putln("Cannot watch for changes: %v", err)
+		exit(1)
+	}
+
+	notifier, err := newInotifyNotifier()
+	if err != nil {
+		writeNoisyOutput("Cannot use inotify, so looking for changes every %v instead: %v", watchPollInterval, err)
+		notifier = pollingNotifier{}
+	}
+
+	// The errors of the first pass were already reported with its results.
+	searchErrors = nil
+
+	files := make(map[string]watchedFile)
+	_, _, added := relistWatchedFiles(lister, files, nil, wantsDirs)
+	notifier.watch(getPathsToWatch(added))
+	writeNoisyOutput("%v=== Watching %v dirs and files for changes ===", osNewLine, len(files))
+	flush()
+
+	for {
+		dirs, isAll := notifier.wait()
+		if isAll {
+			dirs = nil
+		} else if len(dirs) == 0 {
+			continue
+		}
+
+		changed, removed, added := relistWatchedFiles(lister, files, dirs, wantsDirs)
+		notifier.watch(getPathsToWatch(added))
+
+		if (len(changed) > 0) || (len(removed) > 0) {
+			sort.Strings(changed)
+			sort.Strings(removed)
+			searchChangedFiles(changed, removed)
+			flush()
+		}
+	}
+}
+
+// Lists again the entries of the given dirs, and of the dirs appearing
+// within them, or everything if dirs is nil, and updates the files.
+// Returns the files that changed or appeared, with the dirs that appeared
+// if they are wanted, the dirs and files that went away, and all the ones
+// that appeared.
+func relistWatchedFiles(lister *findfile.Searcher, files map[string]watchedFile, dirs []string,
+	wantsDirs bool) (changed, removed []string, added map[string]watchedFile) {
+	isAll := (dirs == nil)
+	added = make(map[string]watchedFile)
+	listed := make(map[string]bool)
+	relistedDirs := make(map[string]bool)
+	for {
+		var newDirs []string
+		for path, file := range listWatchedFiles(lister, dirs) {
+			oldFile, existed := files[path]
+			if !existed {
+				added[path] = file
+			}
+			if file.isDir {
+				if !existed {
+					newDirs = append(newDirs, path)
+					if wantsDirs {
+						changed = append(changed, path)
+					}
+				}
+			} else if !existed || (oldFile.size != file.size) || !oldFile.modTime.Equal(file.modTime) {
+				changed = append(changed, path)
+			}
+			files[path] = file
+			listed[path] = true
+		}
+		for _, dir := range dirs {
+			relistedDirs[dir] = true
+		}
+
+		// The dirs that appeared were not watched yet.
+		if isAll || (len(newDirs) == 0) {
+			break
+		}
+		dirs = newDirs
+	}
+
+	// Whatever was within a dir that went away went with it.
+	var removedDirs []string
+	for path, file := range files {
+		if !listed[path] && (isAll || relistedDirs[filepath.Dir(path)]) {
+			removed = append(removed, path)
+			delete(files, path)
+			if file.isDir {
+				removedDirs = append(removedDirs, path+string(filepath.Separator))
+			}
+		}
+	}
+	if len(removedDirs) > 0 {
+		for path := range files {
+			for _, dir := range removedDirs {
+				if strings.HasPrefix(path, dir) {
+					removed = append(removed, path)
+					delete(files, path)
+					break
+				}
+			}
+		}
+	}
+	return changed, removed, added
+}
+
+// Lists the entries of the given dirs, or everything if dirs is nil.
+func listWatchedFiles(lister *findfile.Searcher, dirs []string) map[string]watchedFile {
+	files := make(map[string]watchedFile)
+	search := lister.Search
+	if dirs != nil {
+		search = func(ctx context.Context, resultFunc findfile.ResultFunc) error {
+			return lister.SearchDirs(ctx, dirs, resultFunc)
+		}
+	}
+	err := search(context.Background(), func(result *findfile.Result) error {
+		if (result.Kind == findfile.ResultEntry) && (result.Info != nil) {
+			files[result.Path] = watchedFile{
+				isDir:   result.IsDir,
+				size:    result.Info.Size(),
+				modTime: result.Info.ModTime(),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeNoisyOutput("Cannot list the dirs and files to watch: %v", err)
+	}
+	return files
+}
+
+// Dirs are watched for their entries, and so are the dirs holding the
+// files and dirs, which includes those of the files given on their own.
+func getPathsToWatch(files map[string]watchedFile) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for path, file := range files {
+		dirs := []string{filepath.Dir(path)}
+		if file.isDir {
+			dirs = append(dirs, path)
+		}
+		for _, dir := range dirs {
+			if !seen[dir] {
+				seen[dir] = true
+				paths = append(paths, dir)
+			}
+		}
+	}
+	return paths
+}
+
+func searchChangedFiles(changed, removed []string) {
+	defer reportWatchErrors()
+
+	// Results that are still there are matched up with the previous ones.
+	oldResults := make(map[string][]watchedResult)
+	remaining := make(map[string]int)
+	for _, path := range changed {
+		for _, r := range watchResults[path] {
+			remaining[r.key]++
+		}
+		oldResults[path] = watchResults[path]
+		delete(watchResults, path)
+	}
+
+	var appeared []*findfile.Result
+	err := searcher.SearchPaths(context.Background(), changed, func(result *findfile.Result) error {
+		r, ok := newWatchedResult(result)
+		if !ok {
+			return nil
+		}
+		if remaining[r.key] > 0 {
+			remaining[r.key]--
+		} else {
+			appeared = append(appeared, result)
+		}
+		recordWatchResult(result)
+		return nil
+	})
+	if err != nil {
+		writeNoisyOutput("Cannot search the changed dirs and files: %v", err)
+	}
+
+	var disappeared []string
+	for _, path := range changed {
+		for _, r := range oldResults[path] {
+			if remaining[r.key] > 0 {
+				remaining[r.key]--
+				disappeared = append(disappeared, r.description)
+			}
+		}
+	}
+	for _, path := range removed {
+		for _, r := range watchResults[path] {
+			disappeared = append(disappeared, r.description)
+		}
+		delete(watchResults, path)
+	}
+
+	if (len(appeared) == 0) && (len(disappeared) == 0) {
+		return
+	}
+
+	writeNoisyOutput("%v=== %v: %v appeared and %v disappeared in %v changed and %v removed dirs and files ===",
+		osNewLine, time.Now().Format("15:04:05"), len(appeared), len(disappeared), len(changed), len(removed))
+
+	// Each pass shows up to the maximum number of results.
+	currentNumResults = optionFirstResult.value - 1
+	for _, result := range appeared {
+		if err := visitResult(result); err != nil {
+			break
+		}
+	}
+	for _, description := range disappeared {
+		putln("Disappeared: %v", description)
+	}
+}
+
+// Reports the paths that could not be read in a pass, which are forgotten
+// afterwards so that each pass reports only its own.
+func reportWatchErrors() {
+	sort.SliceStable(searchErrors, func(i, j int) bool {
+		return searchErrors[i].path < searchErrors[j].path
+	})
+	for _, searchError := range searchErrors {
+		writeNoisyOutput("Could not read %v: %v", searchError.path, searchError.reason)
+	}
+	searchErrors = nil
+}
+
+/**************************************************************************/
@@ -0,0 +1,144 @@
+//go:build linux
+// +build linux
+
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"sort"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+/**************************************************************************/
+
+// Watching for changes with inotify.
+
+const inotifyEvents = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_ATTRIB | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+type inotifyNotifier struct {
+	fd int
+
+	// Watched paths by watch descriptor, and the other way around.
+	paths   map[int]string
+	watched map[string]int
+
+	buffer []byte
+}
+
+func newInotifyNotifier() (changeNotifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_NONBLOCK | unix.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	return &inotifyNotifier{
+		fd:      fd,
+		paths:   make(map[int]string),
+		watched: make(map[string]int),
+		buffer:  make([]byte, 64*1024),
+	}, nil
+}
+
+func (n *inotifyNotifier) watch(paths []string) {
+	for _, path := range paths {
+		if _, ok := n.watched[path]; ok {
+			continue
+		}
+		wd, err := unix.InotifyAddWatch(n.fd, path, inotifyEvents)
+		if err != nil {
+			writeNoisyOutput("Cannot watch %v for changes: %v", path, err)
+			continue
+		}
+		n.paths[wd] = path
+		n.watched[path] = wd
+	}
+}
+
+// Each event tells which watched dir had an entry change. The entries are
+// not needed, since the whole dir is listed again.
+func (n *inotifyNotifier) wait() ([]string, bool) {
+	dirs := make(map[string]bool)
+	isOverflowed := false
+	timeout := -1
+	for {
+		fds := []unix.PollFd{{Fd: int32(n.fd), Events: unix.POLLIN}}
+		count, err := unix.Poll(fds, timeout)
+		if err == unix.EINTR {
+			continue
+		}
+		if (err != nil) || (count == 0) {
+			break
+		}
+		for {
+			size, err := unix.Read(n.fd, n.buffer)
+			if (err != nil) || (size <= 0) {
+				break
+			}
+			isOverflowed = n.readEvents(n.buffer[:size], dirs) || isOverflowed
+		}
+
+		// Wait for the changes to settle down.
+		timeout = int(watchSettleDelay.Milliseconds())
+	}
+
+	paths := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		paths = append(paths, dir)
+	}
+	sort.Strings(paths)
+	return paths, isOverflowed
+}
+
+// Adds the dirs of the events, returning true if events were lost.
+func (n *inotifyNotifier) readEvents(buffer []byte, dirs map[string]bool) bool {
+	isOverflowed := false
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buffer); {
+		event := (*unix.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+		offset += unix.SizeofInotifyEvent + int(event.Len)
+
+		if event.Mask&unix.IN_Q_OVERFLOW != 0 {
+			isOverflowed = true
+			continue
+		}
+		path, ok := n.paths[int(event.Wd)]
+		if !ok {
+			continue
+		}
+		dirs[path] = true
+
+		// The kernel drops the watch when the dir goes away, so it is
+		// watched again if it comes back.
+		if event.Mask&unix.IN_IGNORED != 0 {
+			delete(n.paths, int(event.Wd))
+			delete(n.watched, path)
+		}
+	}
+	return isOverflowed
+}
+
+/**************************************************************************/
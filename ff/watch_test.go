@@ -0,0 +1,85 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"ff/findfile"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Watching for changes.
+
+// Each pass reports the paths it could not read, and only those.
+func TestWatchPassErrors(t *testing.T) {
+	var noisyOutput []string
+	oldWriteNoisyOutput, oldSearcher := writeNoisyOutput, searcher
+	writeNoisyOutput = func(format string, a ...interface{}) {
+		noisyOutput = append(noisyOutput, fmt.Sprintf(format, a...))
+	}
+	watchResults = make(map[string][]watchedResult)
+	defer func() {
+		writeNoisyOutput, searcher = oldWriteNoisyOutput, oldSearcher
+		watchResults, searchErrors = nil, nil
+	}()
+
+	options := findfile.DefaultOptions()
+	options.FS = fstest.MapFS{"a.txt": {Data: []byte("hello\n")}}
+	options.SearchStrings = []string{"hello"}
+	options.OnError = recordSearchError
+	var err error
+	searcher, err = findfile.NewSearcher(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		noisyOutput = nil
+		searchChangedFiles([]string{"gone.txt"}, nil)
+		if len(noisyOutput) != 1 {
+			t.Fatalf("pass %v: got %q, want one error", pass, noisyOutput)
+		}
+		if len(searchErrors) != 0 {
+			t.Errorf("pass %v: got %v errors left over", pass, len(searchErrors))
+		}
+	}
+
+	noisyOutput = nil
+	searchChangedFiles([]string{"a.txt"}, nil)
+	if len(searchErrors) != 0 {
+		t.Errorf("got %v errors left over", len(searchErrors))
+	}
+	for _, line := range noisyOutput {
+		if strings.HasPrefix(line, "Could not read") {
+			t.Errorf("got error %q for a readable file", line)
+		}
+	}
+}
+
+/**************************************************************************/
@@ -0,0 +1,252 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+/**************************************************************************/
+
+// Searching inside archives.
+
+// DefaultMaxArchiveDepth is the number of levels of archives within
+// archives searched when Options.MaxArchiveDepth is 0.
+const DefaultMaxArchiveDepth = 3
+
+// MaxNestedZipSize is the size of the largest zip archive within another
+// archive that is searched, since such zip archives are read into memory.
+// Larger ones are reported as errors and skipped.
+const MaxNestedZipSize = 64 << 20
+
+// ArchiveSeparator separates the path of an archive from the path of an
+// entry within it, e.g. "outer.zip!/inner/path.txt".
+const ArchiveSeparator = "!/"
+
+type archiveFormat int
+
+const (
+	notArchive archiveFormat = iota
+	zipArchive
+	tarArchive
+	tarGzArchive
+)
+
+// Archives are recognized by their file name extensions.
+var archiveFormatsBySuffix = []struct {
+	suffix string
+	format archiveFormat
+}{
+	{".zip", zipArchive},
+	{".jar", zipArchive},
+	{".war", zipArchive},
+	{".tar", tarArchive},
+	{".tar.gz", tarGzArchive},
+	{".tgz", tarGzArchive},
+}
+
+// Tells how to open the file as an archive, if it is one that should be
+// searched at the given level of archives within archives.
+func (run *searchRun) archiveFormatOf(name string, archiveDepth int) archiveFormat {
+	if !run.options.SearchArchives || archiveDepth >= run.options.MaxArchiveDepth {
+		return notArchive
+	}
+
+	name = strings.ToLower(name)
+	for _, f := range archiveFormatsBySuffix {
+		if strings.HasSuffix(name, f.suffix) {
+			return f.format
+		}
+	}
+	return notArchive
+}
+
+func (w *searchWorker) searchArchiveFile(filePath, relPath string, format archiveFormat) error {
+	fileHandle, err := w.fsys.Open(filePath)
+	if err != nil {
+		w.reportError(filePath, err)
+		return nil
+	}
+	defer fileHandle.Close()
+
+	return w.searchArchive(filePath, relPath, format, fileHandle, 1)
+}
+
+// Reports and searches each entry of an archive, which may itself be an
+// entry of another archive.
+func (w *searchWorker) searchArchive(archivePath, archiveRelPath string, format archiveFormat,
+	reader io.Reader, archiveDepth int) error {
+	var err error
+	switch format {
+	case zipArchive:
+		err = w.searchZipArchive(archivePath, archiveRelPath, reader, archiveDepth)
+	case tarGzArchive:
+		var gzipReader *gzip.Reader
+		if gzipReader, err = gzip.NewReader(reader); err == nil {
+			err = w.searchTarArchive(archivePath, archiveRelPath, gzipReader, archiveDepth)
+		}
+	default:
+		err = w.searchTarArchive(archivePath, archiveRelPath, reader, archiveDepth)
+	}
+
+	// Bad archives are not worth stopping the search for.
+	if (err != nil) && !w.isCancelled() {
+		w.reportError(archivePath, err)
+		return nil
+	}
+	return err
+}
+
+func (w *searchWorker) searchZipArchive(archivePath, archiveRelPath string, reader io.Reader, archiveDepth int) error {
+	// Zip files are read from the end, so nested ones are read into memory.
+	readerAt, size, err := toReaderAt(reader)
+	if err != nil {
+		return err
+	}
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range zipReader.File {
+		if w.isCancelled() {
+			return nil
+		}
+		file := file
+		open := func() (io.ReadCloser, error) { return file.Open() }
+		if err := w.visitArchiveEntry(archivePath, archiveRelPath, file.Name, file.FileInfo(), open, archiveDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *searchWorker) searchTarArchive(archivePath, archiveRelPath string, reader io.Reader, archiveDepth int) error {
+	tarReader := tar.NewReader(reader)
+	for !w.isCancelled() {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fileInfo := header.FileInfo()
+		if !fileInfo.IsDir() && !fileInfo.Mode().IsRegular() {
+			continue
+		}
+		open := func() (io.ReadCloser, error) { return io.NopCloser(tarReader), nil }
+		if err := w.visitArchiveEntry(archivePath, archiveRelPath, header.Name, fileInfo, open, archiveDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toReaderAt(reader io.Reader) (io.ReaderAt, int64, error) {
+	if file, ok := reader.(fs.File); ok {
+		if readerAt, ok := reader.(io.ReaderAt); ok {
+			fileInfo, err := file.Stat()
+			if err != nil {
+				return nil, 0, err
+			}
+			return readerAt, fileInfo.Size(), nil
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, MaxNestedZipSize+1))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(data) > MaxNestedZipSize {
+		return nil, 0, fmt.Errorf("nested zip archive larger than %v bytes, skipped", MaxNestedZipSize)
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+func (w *searchWorker) visitArchiveEntry(archivePath, archiveRelPath, name string, fileInfo fs.FileInfo,
+	open func() (io.ReadCloser, error), archiveDepth int) error {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return nil
+	}
+	entryPath := archivePath + ArchiveSeparator + name
+	entryRelPath := archiveRelPath + ArchiveSeparator + name
+
+	if !w.shouldIncludeArchiveEntry(archiveRelPath, name, fileInfo, archiveDepth) ||
+		!w.isWantedType(fileTypeOf(fileInfo.Mode())) {
+		return nil
+	}
+
+	format := w.archiveFormatOf(name, archiveDepth)
+	showName := (format == notArchive) || w.matcher.shouldIncludeFileByNameFilters(path.Base(name), entryRelPath)
+	searchContents, err := w.visitName(entryPath, fileInfo, format, showName)
+	if !searchContents || (err != nil) {
+		return err
+	}
+
+	entryReader, err := open()
+	if err != nil {
+		w.reportError(entryPath, err)
+		return nil
+	}
+	defer entryReader.Close()
+
+	if format != notArchive {
+		return w.searchArchive(entryPath, entryRelPath, format, entryReader, archiveDepth+1)
+	}
+	return w.searchContents(entryPath, entryReader)
+}
+
+// Applies the dir filters to each dir of the entry within the archive,
+// and the other filters to the entry itself.
+func (w *searchWorker) shouldIncludeArchiveEntry(archiveRelPath, name string, fileInfo fs.FileInfo, archiveDepth int) bool {
+	for pos := strings.IndexByte(name, '/'); pos >= 0; pos = nextSlash(name, pos) {
+		dirName := name[:pos]
+		if !w.matcher.shouldIncludeDirByNameFilters(path.Base(dirName), archiveRelPath+ArchiveSeparator+dirName) {
+			return false
+		}
+	}
+	return w.shouldInclude("", fileInfo, archiveRelPath+ArchiveSeparator+name, archiveDepth)
+}
+
+func nextSlash(name string, pos int) int {
+	next := strings.IndexByte(name[pos+1:], '/')
+	if next < 0 {
+		return -1
+	}
+	return pos + 1 + next
+}
+
+/**************************************************************************/
@@ -0,0 +1,173 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Searching inside archives.
+
+func zipForTest(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, data := range files {
+		fileWriter, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fileWriter.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func tarGzForTest(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	writer := tar.NewWriter(gzipWriter)
+	for name, data := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func archiveFSForTest(t *testing.T) fstest.MapFS {
+	inner := zipForTest(t, map[string][]byte{"deep.txt": []byte("hello from the inner zip\n")})
+	return fstest.MapFS{
+		"a.zip": {Data: zipForTest(t, map[string][]byte{
+			"dir/hello.txt": []byte("hello world\n"),
+			"dir/other.txt": []byte("goodbye\n"),
+		})},
+		"b.tgz": {Data: tarGzForTest(t, map[string][]byte{
+			"x.txt":     []byte("say hello\n"),
+			"inner.zip": inner,
+		})},
+		"plain.txt": {Data: []byte("hello plain\n")},
+	}
+}
+
+func TestArchives(t *testing.T) {
+	options := newTestOptions(archiveFSForTest(t))
+	options.SearchStrings = []string{"hello"}
+	options.SearchContentsOnly = true
+	options.SearchArchives = true
+
+	got := resultPaths(searchForTest(t, options), ResultLine)
+	want := []string{
+		"a.zip!/dir/hello.txt",
+		"b.tgz!/inner.zip!/deep.txt",
+		"b.tgz!/x.txt",
+		"plain.txt",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestArchivesNotSearched(t *testing.T) {
+	options := newTestOptions(archiveFSForTest(t))
+	options.SearchStrings = []string{"hello"}
+	options.SearchContentsOnly = true
+
+	got := resultPaths(searchForTest(t, options), ResultLine)
+	want := []string{"plain.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestArchiveDepth(t *testing.T) {
+	options := newTestOptions(archiveFSForTest(t))
+	options.SearchStrings = []string{"hello"}
+	options.SearchContentsOnly = true
+	options.SearchArchives = true
+	options.MaxArchiveDepth = 1
+
+	got := resultPaths(searchForTest(t, options), ResultLine)
+	want := []string{"a.zip!/dir/hello.txt", "b.tgz!/x.txt", "plain.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// A bad archive is reported, and the search goes on.
+func TestBadArchive(t *testing.T) {
+	fsys := archiveFSForTest(t)
+	fsys["bad.zip"] = &fstest.MapFile{Data: []byte("hello, not a zip archive\n")}
+	options := newTestOptions(fsys)
+	options.SearchStrings = []string{"hello"}
+	options.SearchContentsOnly = true
+	options.SearchArchives = true
+
+	results, errorPaths := searchWithErrorsForTest(t, options)
+	if want := []string{"bad.zip"}; !reflect.DeepEqual(errorPaths, want) {
+		t.Errorf("got errors for %q, want %q", errorPaths, want)
+	}
+	if got := resultPaths(results, ResultLine); len(got) != 4 {
+		t.Errorf("got %q, want the 4 other matches", got)
+	}
+}
+
+func TestNestedZipSizeLimit(t *testing.T) {
+	// Readers that are not files are read into memory, up to the limit.
+	if _, _, err := toReaderAt(bytes.NewReader(make([]byte, MaxNestedZipSize+1))); err == nil {
+		t.Errorf("got no error for a nested zip archive of %v bytes", MaxNestedZipSize+1)
+	}
+
+	_, size, err := toReaderAt(strings.NewReader("small"))
+	if (err != nil) || (size != 5) {
+		t.Errorf("got size %v and error %v, want 5 and none", size, err)
+	}
+}
+
+/**************************************************************************/
@@ -0,0 +1,118 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+/**************************************************************************/
+
+// Binary files.
+
+// BinaryFilePolicy tells what to do with the files that look binary.
+type BinaryFilePolicy int
+
+const (
+	// Don't search binary files.
+	BinaryFilesSkip BinaryFilePolicy = iota
+
+	// Search binary files like text files.
+	BinaryFilesSearch
+
+	// Report each matching binary file once as a ResultBinaryFile, with the
+	// byte offset of its first match.
+	BinaryFilesReport
+)
+
+// Magic numbers of common binary formats that could otherwise pass for text.
+var binaryMagicNumbers = [][]byte{
+	[]byte("\x7fELF"),
+	[]byte("\x89PNG\r\n\x1a\n"),
+	[]byte("GIF87a"),
+	[]byte("GIF89a"),
+	[]byte("\xff\xd8\xff"),
+	[]byte("%PDF-"),
+	[]byte("PK\x03\x04"),
+	[]byte("\x1f\x8b"),
+	[]byte("\xfd7zXZ\x00"),
+	[]byte("7z\xbc\xaf\x27\x1c"),
+	[]byte("\xca\xfe\xba\xbe"),
+	[]byte("\xfe\xed\xfa\xce"),
+	[]byte("\xfe\xed\xfa\xcf"),
+	[]byte("\xce\xfa\xed\xfe"),
+	[]byte("\xcf\xfa\xed\xfe"),
+	[]byte("\x00asm"),
+	[]byte("SQLite format 3\x00"),
+}
+
+// Tells whether the first bytes of a file look binary: starting with a
+// known magic number, or with too many NULs, invalid UTF-8 or control
+// characters other than the ones found in text, such as form feeds.
+// NULs are text when they separate records.
+func isBinarySample(sample []byte, isNULText bool) bool {
+	if len(sample) == 0 {
+		return false
+	}
+	for _, magic := range binaryMagicNumbers {
+		if bytes.HasPrefix(sample, magic) {
+			return true
+		}
+	}
+
+	numNULs, numInvalid, numControls := 0, 0, 0
+	for data := sample; len(data) > 0; {
+		r, size := utf8.DecodeRune(data)
+		switch {
+		case r == 0:
+			if !isNULText {
+				numNULs++
+			}
+		case (r == utf8.RuneError) && (size <= 1):
+			// A last character cut in two is still text.
+			if utf8.FullRune(data) {
+				numInvalid++
+			}
+		case (r < ' ') && !isTextControlCharacter(r):
+			numControls++
+		}
+		data = data[size:]
+	}
+
+	return (numNULs*1000 >= len(sample)) ||
+		(numInvalid*100 >= len(sample)*30) ||
+		(numControls*100 >= len(sample))
+}
+
+func isTextControlCharacter(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', '\x1b':
+		return true
+	}
+	return false
+}
+
+/**************************************************************************/
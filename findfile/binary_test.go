@@ -0,0 +1,111 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Binary files.
+
+func TestIsBinarySample(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		want   bool
+	}{
+		{"empty", "", false},
+		{"text", "plain text\n", false},
+		{"text controls", "a\tb\fc\x1b[0m\r\n", false},
+		{"utf-8", "héllo wörld 日本語\n", false},
+		{"last character cut", "héllo \xe6\x97", false},
+		{"a few invalid bytes", "caf\xe9 au lait\n", false},
+		{"elf", "\x7fELF plain text", true},
+		{"pdf", "%PDF-1.7\n", true},
+		{"nul", "text\x00text", true},
+		{"rare nul", strings.Repeat("text ", 400) + "\x00", false},
+		{"mostly invalid", "\xff\xfe\xfa\xc0\xc1 ab", true},
+		{"controls", "a\x01b\x02c\x03", true},
+	}
+	for _, test := range tests {
+		if got := isBinarySample([]byte(test.sample), false); got != test.want {
+			t.Errorf("%v: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDecodeTextBinary(t *testing.T) {
+	binary := "text\x00with\x00\x01\x02\x03 bytes\x00\xff\xfe"
+	if _, _, isBinary := decodeText(strings.NewReader(binary), "", false); !isBinary {
+		t.Errorf("not taken as binary")
+	}
+	if _, _, isBinary := decodeText(strings.NewReader("one\x00two\x00three\x00"), "", true); isBinary {
+		t.Errorf("NUL-separated text taken as binary")
+	}
+	if _, _, isBinary := decodeText(strings.NewReader(binary), EncodingUTF16LE, false); isBinary {
+		t.Errorf("text in a given 16-bit encoding taken as binary")
+	}
+}
+
+func TestBinaryFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data.bin": {Data: []byte("\x00\x01\x02\x03 some needle\x00 needle")},
+		"text.txt": {Data: []byte("a needle\n")},
+	}
+	tests := []struct {
+		binaryFiles BinaryFilePolicy
+		want        []string
+	}{
+		{BinaryFilesSkip, []string{"line text.txt"}},
+		{BinaryFilesSearch, []string{"line data.bin", "line text.txt"}},
+		{BinaryFilesReport, []string{"binary data.bin 10", "line text.txt"}},
+	}
+	for _, test := range tests {
+		options := newTestOptions(fsys)
+		options.SearchStrings = []string{"needle"}
+		options.BinaryFiles = test.binaryFiles
+
+		var got []string
+		for _, result := range searchForTest(t, options) {
+			switch result.Kind {
+			case ResultLine:
+				got = append(got, "line "+result.Path)
+			case ResultBinaryFile:
+				got = append(got, fmt.Sprintf("binary %v %v", result.Path, result.Offset))
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("policy %v: got %q, want %q", test.binaryFiles, got, test.want)
+		}
+	}
+}
+
+/**************************************************************************/
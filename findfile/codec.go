@@ -0,0 +1,154 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"sync"
+)
+
+/**************************************************************************/
+
+// Decompressing files.
+
+// A codec decompresses files starting with its magic bytes, or with the
+// header it tells apart itself.
+type codec struct {
+	name      string
+	magic     string
+	isHeader  func(header []byte) bool
+	newReader func(io.Reader) (io.Reader, error)
+}
+
+// Number of bytes of a file that a codec must decompress without error
+// before the file is taken as compressed, since magic bytes this short
+// also begin some text files.
+const codecSampleSize = 4096
+
+// Number of decompressed bytes of the sample after which a codec is taken
+// to match, without decompressing the rest of the sample.
+const maxCodecSampleOutput = 64 << 10
+
+var (
+	codecsMutex sync.RWMutex
+	codecs      []codec
+)
+
+func init() {
+	RegisterCodec("gzip", "\x1f\x8b", func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	})
+	RegisterCodec("bzip2", "BZh", func(r io.Reader) (io.Reader, error) {
+		return bzip2.NewReader(r), nil
+	})
+
+	registerCodec("zlib", "??", isZlibHeader, func(r io.Reader) (io.Reader, error) {
+		return zlib.NewReader(r)
+	})
+}
+
+// Zlib headers use the deflate method, and are a multiple of 31.
+func isZlibHeader(header []byte) bool {
+	return (len(header) >= 2) && (header[0]&0x0f == 8) && ((int(header[0])<<8|int(header[1]))%31 == 0)
+}
+
+// RegisterCodec adds a decompressor used with Options.Decompress, for the
+// files starting with the given magic bytes, where '?' matches any byte.
+// A codec registered later takes precedence over one with the same magic.
+func RegisterCodec(name, magic string, newReader func(io.Reader) (io.Reader, error)) {
+	registerCodec(name, magic, nil, newReader)
+}
+
+func registerCodec(name, magic string, isHeader func([]byte) bool, newReader func(io.Reader) (io.Reader, error)) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	codecs = append([]codec{{name: name, magic: magic, isHeader: isHeader, newReader: newReader}}, codecs...)
+}
+
+func findCodec(header []byte) *codec {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	for i := range codecs {
+		if matchesMagic(header, codecs[i].magic) &&
+			((codecs[i].isHeader == nil) || codecs[i].isHeader(header)) &&
+			canDecompress(&codecs[i], header) {
+			return &codecs[i]
+		}
+	}
+	return nil
+}
+
+// Tells whether the codec decompresses the first bytes of a file without
+// error, where running out of bytes is only an error if the whole file
+// was sampled.
+func canDecompress(c *codec, sample []byte) bool {
+	isCut := len(sample) == codecSampleSize
+	r, err := c.newReader(bytes.NewReader(sample))
+	if err != nil {
+		return false
+	}
+	n, err := io.Copy(io.Discard, io.LimitReader(r, maxCodecSampleOutput))
+	switch {
+	case n == maxCodecSampleOutput:
+		return true
+	case err == nil:
+		return true
+	default:
+		return isCut && (err == io.ErrUnexpectedEOF)
+	}
+}
+
+func matchesMagic(header []byte, magic string) bool {
+	if len(header) < len(magic) {
+		return false
+	}
+	for i := 0; i < len(magic); i++ {
+		if magic[i] != '?' && magic[i] != header[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a reader of the decompressed contents if the file starts with
+// the magic bytes of a codec and its first bytes decompress, or else a
+// reader of the file as it is.
+func decompressIfNeeded(file io.Reader) (io.Reader, error) {
+	bufferedReader := bufio.NewReaderSize(file, codecSampleSize)
+	header, _ := bufferedReader.Peek(codecSampleSize)
+	if c := findCodec(header); c != nil {
+		return c.newReader(bufferedReader)
+	}
+	return bufferedReader, nil
+}
+
+/**************************************************************************/
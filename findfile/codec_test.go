@@ -0,0 +1,146 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Decompressing files.
+
+// "hello bzip2\n", compressed with bzip2.
+const bzip2Hello = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xab\x6b\xa1\xf1\x00\x00" +
+	"\x02\xd9\x80\x00\x10\x40\x00\x10\x00\x12\x64\xc0\x10\x20\x00\x31" +
+	"\x00\xd3\x4d\x04\x00\x1e\xa3\xef\x4e\x51\xa2\x07\x8b\xb9\x22\x9c" +
+	"\x28\x48\x55\xb5\xd0\xf8\x80"
+
+func gzipForTest(text string) string {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(text))
+	writer.Close()
+	return compressed.String()
+}
+
+func zlibForTest(text string) string {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write([]byte(text))
+	writer.Close()
+	return compressed.String()
+}
+
+// Random words, which compress to much more than a codec sample.
+func wordsForTest(numWords int) string {
+	words := []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta", "eta", "theta"}
+	random := rand.New(rand.NewSource(1))
+	var text strings.Builder
+	for i := 0; i < numWords; i++ {
+		text.WriteString(words[random.Intn(len(words))])
+		if random.Intn(10) == 0 {
+			text.WriteByte('\n')
+		} else {
+			text.WriteByte(' ')
+		}
+	}
+	return text.String()
+}
+
+func TestDecompressIfNeeded(t *testing.T) {
+	words := wordsForTest(200000)
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"gzip", gzipForTest("hello gzip\n"), "hello gzip\n"},
+		{"large gzip", gzipForTest(words), words},
+		{"bzip2", bzip2Hello, "hello bzip2\n"},
+		{"zlib", zlibForTest("hello zlib\n"), "hello zlib\n"},
+		{"large zlib", zlibForTest(words), words},
+		{"empty zlib", zlibForTest(""), ""},
+		{"text like a zlib header", "x^2 hello\n", "x^2 hello\n"},
+		{"text like a bzip2 header", "BZh nothing hello\n", "BZh nothing hello\n"},
+		{"text like a bzip2 block", "BZh91AY&SY hello\n", "BZh91AY&SY hello\n"},
+		{"large text", words, words},
+		{"empty", "", ""},
+	}
+	for _, test := range tests {
+		reader, err := decompressIfNeeded(strings.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		got, err := io.ReadAll(reader)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, truncateForTest(string(got)), truncateForTest(test.want))
+		}
+	}
+}
+
+func truncateForTest(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
+
+func TestSearchDecompressed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.gz":   {Data: []byte(gzipForTest("one\nhello gzip\n"))},
+		"b.bz2":  {Data: []byte(bzip2Hello)},
+		"c.txt":  {Data: []byte("x^2 hello\n")},
+		"d.zlib": {Data: []byte(zlibForTest("hello zlib\n"))},
+	}
+	options := newTestOptions(fsys)
+	options.SearchStrings = []string{"hello"}
+	options.Decompress = true
+
+	var got []string
+	for _, result := range searchForTest(t, options) {
+		if result.Kind == ResultLine {
+			got = append(got, result.Path+": "+result.Text)
+		}
+	}
+	want := []string{"a.gz: hello gzip", "b.bz2: hello bzip2", "c.txt: x^2 hello", "d.zlib: hello zlib"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+/**************************************************************************/
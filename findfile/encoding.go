@@ -0,0 +1,301 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/**************************************************************************/
+
+// Text encodings.
+
+// Names of the encodings that files can be decoded from, for
+// Options.Encoding.
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingUTF32LE     = "utf-32le"
+	EncodingUTF32BE     = "utf-32be"
+	EncodingLatin1      = "latin-1"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// Number of bytes looked at to guess the encoding of a file.
+const encodingSampleSize = 4096
+
+// Byte order marks, longest first since the UTF-32LE one starts with the
+// UTF-16LE one.
+var byteOrderMarks = []struct {
+	bom      string
+	encoding string
+}{
+	{"\xff\xfe\x00\x00", EncodingUTF32LE},
+	{"\x00\x00\xfe\xff", EncodingUTF32BE},
+	{"\xef\xbb\xbf", EncodingUTF8},
+	{"\xff\xfe", EncodingUTF16LE},
+	{"\xfe\xff", EncodingUTF16BE},
+}
+
+// Characters of windows-1252 that differ from latin-1, from 0x80 to 0x9f,
+// where 0 marks the bytes left undefined.
+var windows1252Runes = [32]rune{
+	0x20ac, 0, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021,
+	0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017d, 0,
+	0, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+	0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0, 0x017e, 0x0178,
+}
+
+// IsKnownEncoding tells whether the name is one of the Encoding constants.
+func IsKnownEncoding(name string) bool {
+	switch name {
+	case EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingUTF32LE, EncodingUTF32BE,
+		EncodingLatin1, EncodingWindows1252:
+		return true
+	}
+	return false
+}
+
+// Returns a reader of the file decoded to UTF-8, using the given encoding,
+// or else the one told by its byte order mark or guessed from its first
+// bytes, along with the name of that encoding. Files that look binary are
+// not decoded, and have no encoding.
+func decodeText(file io.Reader, encoding string, isNULText bool) (io.Reader, string, bool) {
+	bufferedReader := bufio.NewReaderSize(file, encodingSampleSize)
+	sample, _ := bufferedReader.Peek(encodingSampleSize)
+
+	// A byte order mark is dropped if it fits the encoding.
+	hasByteOrderMark := false
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(sample, []byte(mark.bom)) && ((encoding == "") || (encoding == mark.encoding)) {
+			bufferedReader.Discard(len(mark.bom))
+			encoding, hasByteOrderMark = mark.encoding, true
+			break
+		}
+	}
+
+	isGuessed := (encoding == "")
+	if isGuessed {
+		encoding = guessEncoding(sample)
+	}
+
+	// Only 8-bit encodings can be told apart from binary.
+	if !hasByteOrderMark && (isGuessed || (encoding == EncodingUTF8)) && is8BitEncoding(encoding) &&
+		isBinarySample(sample, isNULText) {
+		return bufferedReader, "", true
+	}
+
+	switch encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return &runeDecoder{src: bufferedReader, decodeRune: utf16RuneDecoder(encoding == EncodingUTF16BE)}, encoding, false
+	case EncodingUTF32LE, EncodingUTF32BE:
+		return &runeDecoder{src: bufferedReader, decodeRune: utf32RuneDecoder(encoding == EncodingUTF32BE)}, encoding, false
+	case EncodingLatin1, EncodingWindows1252:
+		return &runeDecoder{src: bufferedReader, decodeRune: singleByteRuneDecoder(encoding == EncodingWindows1252)}, encoding, false
+	}
+	return bufferedReader, EncodingUTF8, false
+}
+
+func is8BitEncoding(encoding string) bool {
+	return (encoding == EncodingUTF8) || (encoding == EncodingLatin1) || (encoding == EncodingWindows1252)
+}
+
+// Text in UTF-16 or UTF-32 is mostly made of characters below U+0100, which
+// leave some bytes of each code unit NUL in a regular pattern. Other text
+// that is not valid UTF-8 but has no NUL bytes is taken as a legacy
+// single-byte encoding.
+func guessEncoding(sample []byte) string {
+	// Leave out a last code unit cut in two.
+	sample = sample[:len(sample)&^3]
+	if len(sample) == 0 {
+		return EncodingUTF8
+	}
+
+	var numNULs [4]int
+	for pos, b := range sample {
+		if b == 0 {
+			numNULs[pos%4]++
+		}
+	}
+
+	numUnits := len(sample) / 4
+	isMostlyNUL := func(count int) bool { return count*10 >= numUnits*9 }
+	isRarelyNUL := func(count int) bool { return count*10 <= numUnits }
+
+	switch {
+	case isMostlyNUL(numNULs[1]) && isMostlyNUL(numNULs[2]) && isMostlyNUL(numNULs[3]) && isRarelyNUL(numNULs[0]):
+		return EncodingUTF32LE
+	case isMostlyNUL(numNULs[0]) && isMostlyNUL(numNULs[1]) && isMostlyNUL(numNULs[2]) && isRarelyNUL(numNULs[3]):
+		return EncodingUTF32BE
+	case isMostlyNUL(numNULs[1]) && isMostlyNUL(numNULs[3]) && isRarelyNUL(numNULs[0]) && isRarelyNUL(numNULs[2]):
+		return EncodingUTF16LE
+	case isMostlyNUL(numNULs[0]) && isMostlyNUL(numNULs[2]) && isRarelyNUL(numNULs[1]) && isRarelyNUL(numNULs[3]):
+		return EncodingUTF16BE
+	}
+
+	if (numNULs[0]+numNULs[1]+numNULs[2]+numNULs[3] > 0) || isValidUTF8Prefix(sample) {
+		return EncodingUTF8
+	}
+	for _, b := range sample {
+		if (b >= 0x80) && (b <= 0x9f) && (windows1252Runes[b-0x80] != 0) {
+			return EncodingWindows1252
+		}
+	}
+	return EncodingLatin1
+}
+
+// Tells whether the bytes are valid UTF-8, except for a last character
+// that might have been cut in two.
+func isValidUTF8Prefix(data []byte) bool {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if (r == utf8.RuneError) && (size <= 1) {
+			return !utf8.FullRune(data)
+		}
+		data = data[size:]
+	}
+	return true
+}
+
+/**************************************************************************/
+
+// Decoding to UTF-8.
+
+// A runeDecoder reads characters in some encoding, and gives them out in
+// UTF-8. Invalid codes become utf8.RuneError.
+type runeDecoder struct {
+	src        *bufio.Reader
+	decodeRune func(src *bufio.Reader) (rune, error)
+	pending    []byte
+	err        error
+}
+
+func (d *runeDecoder) Read(p []byte) (int, error) {
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+
+	var buffer [utf8.UTFMax]byte
+	for (n < len(p)) && (d.err == nil) {
+		r, err := d.decodeRune(d.src)
+		if err != nil {
+			d.err = err
+			break
+		}
+
+		size := utf8.EncodeRune(buffer[:], r)
+		copied := copy(p[n:], buffer[:size])
+		d.pending = append(d.pending, buffer[copied:size]...)
+		n += copied
+	}
+
+	if (n == 0) && (len(d.pending) == 0) && (d.err != nil) {
+		return 0, d.err
+	}
+	return n, nil
+}
+
+func utf16RuneDecoder(isBigEndian bool) func(src *bufio.Reader) (rune, error) {
+	readUnit := func(src *bufio.Reader) (rune, error) {
+		var unit [2]byte
+		if _, err := io.ReadFull(src, unit[:]); err != nil {
+			return 0, err
+		}
+		if isBigEndian {
+			return rune(unit[0])<<8 | rune(unit[1]), nil
+		}
+		return rune(unit[1])<<8 | rune(unit[0]), nil
+	}
+
+	return func(src *bufio.Reader) (rune, error) {
+		r, err := readUnit(src)
+		if err == io.ErrUnexpectedEOF {
+			return utf8.RuneError, nil
+		}
+		if (err != nil) || !utf16.IsSurrogate(r) {
+			return r, err
+		}
+
+		// A high surrogate must be followed by a low one.
+		next, err := src.Peek(2)
+		if err != nil {
+			return utf8.RuneError, nil
+		}
+		low := rune(next[1])<<8 | rune(next[0])
+		if isBigEndian {
+			low = rune(next[0])<<8 | rune(next[1])
+		}
+		combined := utf16.DecodeRune(r, low)
+		if combined != utf8.RuneError {
+			src.Discard(2)
+		}
+		return combined, nil
+	}
+}
+
+func utf32RuneDecoder(isBigEndian bool) func(src *bufio.Reader) (rune, error) {
+	return func(src *bufio.Reader) (rune, error) {
+		var unit [4]byte
+		n, err := io.ReadFull(src, unit[:])
+		if err == io.ErrUnexpectedEOF {
+			return utf8.RuneError, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var r rune
+		for i := 0; i < n; i++ {
+			if isBigEndian {
+				r = r<<8 | rune(unit[i])
+			} else {
+				r = r<<8 | rune(unit[n-1-i])
+			}
+		}
+		if !utf8.ValidRune(r) {
+			return utf8.RuneError, nil
+		}
+		return r, nil
+	}
+}
+
+func singleByteRuneDecoder(isWindows1252 bool) func(src *bufio.Reader) (rune, error) {
+	return func(src *bufio.Reader) (rune, error) {
+		b, err := src.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if isWindows1252 && (b >= 0x80) && (b <= 0x9f) && (windows1252Runes[b-0x80] != 0) {
+			return windows1252Runes[b-0x80], nil
+		}
+		return rune(b), nil
+	}
+}
+
+/**************************************************************************/
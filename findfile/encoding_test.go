@@ -0,0 +1,91 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+/**************************************************************************/
+
+// Text encodings.
+
+func utf16ForTest(text string, isBigEndian bool) string {
+	var encoded strings.Builder
+	for _, unit := range utf16.Encode([]rune(text)) {
+		if isBigEndian {
+			encoded.WriteByte(byte(unit >> 8))
+			encoded.WriteByte(byte(unit))
+		} else {
+			encoded.WriteByte(byte(unit))
+			encoded.WriteByte(byte(unit >> 8))
+		}
+	}
+	return encoded.String()
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		encoding     string
+		wantEncoding string
+		wantText     string
+	}{
+		{"utf-8", "héllo\n", "", EncodingUTF8, "héllo\n"},
+		{"utf-8 bom", "\xef\xbb\xbfhello\n", "", EncodingUTF8, "hello\n"},
+		{"utf-16le bom", "\xff\xfe" + utf16ForTest("hé€\n", false), "", EncodingUTF16LE, "hé€\n"},
+		{"utf-16be bom", "\xfe\xff" + utf16ForTest("hé€\n", true), "", EncodingUTF16BE, "hé€\n"},
+		{"utf-16le", utf16ForTest("hello world\n", false), "", EncodingUTF16LE, "hello world\n"},
+		{"utf-16be", utf16ForTest("hello world\n", true), "", EncodingUTF16BE, "hello world\n"},
+		{"utf-32le bom", "\xff\xfe\x00\x00h\x00\x00\x00i\x00\x00\x00", "", EncodingUTF32LE, "hi"},
+		{"surrogate pair", "\xff\xfe" + utf16ForTest("a😀b", false), "", EncodingUTF16LE, "a😀b"},
+		{"latin-1", "caf\xe9 au lait\n", "", EncodingLatin1, "café au lait\n"},
+		{"windows-1252", "\x93quoted\x94 \x80\n", "", EncodingWindows1252, "“quoted” €\n"},
+		{"given latin-1", "\x93\n", EncodingLatin1, EncodingLatin1, "\u0093\n"},
+		{"given encoding without its bom", "\xef\xbb\xbfhi", EncodingLatin1, EncodingLatin1, "ï»¿hi"},
+		{"empty", "", "", EncodingUTF8, ""},
+	}
+	for _, test := range tests {
+		reader, encoding, isBinary := decodeText(strings.NewReader(test.data), test.encoding, false)
+		if isBinary {
+			t.Errorf("%v: taken as binary", test.name)
+			continue
+		}
+		text, err := io.ReadAll(reader)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if (encoding != test.wantEncoding) || (string(text) != test.wantText) {
+			t.Errorf("%v: got %v %q, want %v %q", test.name, encoding, text, test.wantEncoding, test.wantText)
+		}
+	}
+}
+
+/**************************************************************************/
@@ -0,0 +1,166 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+/**************************************************************************/
+
+// Named kinds of files.
+
+// FileKind names a kind of file, such as "go" or "web", by the glob
+// patterns matching its names. Files without an extension are also
+// recognized by the interpreter on their "#!" line.
+type FileKind struct {
+	Name         string
+	Globs        []string
+	Interpreters []string
+}
+
+// DefaultFileKinds are the kinds of files known to ff.
+var DefaultFileKinds = []FileKind{
+	{Name: "c", Globs: []string{"*.c", "*.h"}},
+	{Name: "config", Globs: []string{"*.cfg", "*.conf", "*.ini", "*.json", "*.properties", "*.toml", "*.yaml", "*.yml"}},
+	{Name: "cpp", Globs: []string{"*.cc", "*.cpp", "*.cxx", "*.h", "*.hh", "*.hpp", "*.hxx"}},
+	{Name: "cs", Globs: []string{"*.cs", "*.csproj"}},
+	{Name: "css", Globs: []string{"*.css", "*.less", "*.sass", "*.scss"}},
+	{Name: "docs", Globs: []string{"*.adoc", "*.markdown", "*.md", "*.rst", "*.txt"}},
+	{Name: "go", Globs: []string{"*.go", "go.mod", "go.sum", "go.work"}},
+	{Name: "html", Globs: []string{"*.htm", "*.html", "*.xhtml"}},
+	{Name: "java", Globs: []string{"*.java", "*.jsp"}},
+	{Name: "js", Globs: []string{"*.cjs", "*.js", "*.jsx", "*.mjs"}, Interpreters: []string{"node", "nodejs"}},
+	{Name: "kotlin", Globs: []string{"*.kt", "*.kts"}},
+	{Name: "make", Globs: []string{"*.mk", "GNUmakefile", "Makefile", "makefile"}, Interpreters: []string{"make"}},
+	{Name: "perl", Globs: []string{"*.pl", "*.pm", "*.t"}, Interpreters: []string{"perl"}},
+	{Name: "php", Globs: []string{"*.php"}, Interpreters: []string{"php"}},
+	{Name: "py", Globs: []string{"*.py", "*.pyi", "*.pyw"}, Interpreters: []string{"python", "python2", "python3"}},
+	{Name: "ruby", Globs: []string{"*.gemspec", "*.rb", "Gemfile", "Rakefile"}, Interpreters: []string{"ruby"}},
+	{Name: "rust", Globs: []string{"*.rs"}},
+	{Name: "sh", Globs: []string{"*.bash", "*.ksh", "*.sh", "*.zsh"}, Interpreters: []string{"bash", "dash", "ksh", "sh", "zsh"}},
+	{Name: "sql", Globs: []string{"*.sql"}},
+	{Name: "ts", Globs: []string{"*.cts", "*.mts", "*.ts", "*.tsx"}, Interpreters: []string{"deno", "ts-node"}},
+	{Name: "web", Globs: []string{"*.css", "*.htm", "*.html", "*.js", "*.jsx", "*.less", "*.mjs", "*.sass", "*.scss",
+		"*.svelte", "*.ts", "*.tsx", "*.vue"}},
+	{Name: "xml", Globs: []string{"*.xml", "*.xsd", "*.xsl", "*.xslt"}},
+}
+
+// Maximum number of bytes read from a file to find its "#!" line.
+const maxShebangLineSize = 256
+
+// Several file kinds merged into one filter.
+type fileKindFilter struct {
+	globs        *globFilterList
+	interpreters map[string]bool
+}
+
+func newFileKindFilter(kinds []FileKind) (*fileKindFilter, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	var globs []string
+	filter := &fileKindFilter{interpreters: make(map[string]bool)}
+	for _, kind := range kinds {
+		globs = append(globs, kind.Globs...)
+		for _, interpreter := range kind.Interpreters {
+			filter.interpreters[interpreter] = true
+		}
+	}
+
+	var err error
+	if filter.globs, err = newGlobFilterList(trimAll(globs)); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// The interpreter is only looked for in regular files without an extension,
+// and only once for both filters. Pipes, sockets and devices are never
+// opened, since reading them could block.
+func (run *searchRun) shouldIncludeFileByKinds(filePath string, fileInfo fs.FileInfo, relPath string) bool {
+	baseName := fileInfo.Name()
+	interpreter, hasReadInterpreter := "", false
+	matches := func(filter *fileKindFilter) bool {
+		if filter.globs.matches(baseName, relPath, false) {
+			return true
+		}
+		if (len(filter.interpreters) == 0) || (filePath == "") || (path.Ext(baseName) != "") ||
+			!fileInfo.Mode().IsRegular() {
+			return false
+		}
+		if !hasReadInterpreter {
+			interpreter, hasReadInterpreter = run.readInterpreter(filePath), true
+		}
+		return filter.interpreters[interpreter]
+	}
+
+	if (run.includeKinds != nil) && !matches(run.includeKinds) {
+		return false
+	}
+	return (run.excludeKinds == nil) || !matches(run.excludeKinds)
+}
+
+// Returns the name of the interpreter on the "#!" line of the file, such as
+// "python3" for both "#!/usr/bin/python3" and "#!/usr/bin/env python3".
+func (run *searchRun) readInterpreter(filePath string) string {
+	file, err := run.fsys.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	line, _ := bufio.NewReaderSize(file, maxShebangLineSize).Peek(maxShebangLineSize)
+	if !bytes.HasPrefix(line, []byte("#!")) {
+		return ""
+	}
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+
+	fields := strings.Fields(string(line[2:]))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// Skip the options of env, such as -S.
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = path.Base(field)
+				break
+			}
+		}
+	}
+	return interpreter
+}
+
+/**************************************************************************/
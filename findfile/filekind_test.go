@@ -0,0 +1,120 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"io/fs"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Named kinds of files.
+
+func fileKindsForTest(names ...string) []FileKind {
+	var kinds []FileKind
+	for _, kind := range DefaultFileKinds {
+		for _, name := range names {
+			if kind.Name == name {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	return kinds
+}
+
+// Records the files opened.
+type openRecordingFS struct {
+	fstest.MapFS
+	mutex  sync.Mutex
+	opened []string
+}
+
+func (fsys *openRecordingFS) Open(name string) (fs.File, error) {
+	fsys.mutex.Lock()
+	fsys.opened = append(fsys.opened, name)
+	fsys.mutex.Unlock()
+	return fsys.MapFS.Open(name)
+}
+
+func TestFileKinds(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":        {},
+		"lib/util.py":    {},
+		"bin/tool":       {Data: []byte("#!/usr/bin/env -S python3 -u\nprint()\n")},
+		"bin/build":      {Data: []byte("#!/bin/sh\nmake\n")},
+		"bin/data":       {Data: []byte("no shebang\n")},
+		"bin/script.txt": {Data: []byte("#!/usr/bin/python3\n")},
+		"README.md":      {},
+	}
+	tests := []struct {
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{[]string{"py"}, nil, []string{"bin/tool", "lib/util.py"}},
+		{[]string{"py", "sh"}, nil, []string{"bin/build", "bin/tool", "lib/util.py"}},
+		{nil, []string{"py", "docs"}, []string{"bin/build", "bin/data", "main.go"}},
+		{[]string{"go", "py"}, []string{"py"}, []string{"main.go"}},
+	}
+	for _, test := range tests {
+		options := newTestOptions(fsys)
+		options.ListAll = true
+		options.Types = []FileType{TypeFile}
+		options.IncludeKinds = fileKindsForTest(test.include...)
+		options.ExcludeKinds = fileKindsForTest(test.exclude...)
+
+		got := resultPaths(searchForTest(t, options), ResultEntry)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("kinds %v but not %v: got %q, want %q", test.include, test.exclude, got, test.want)
+		}
+	}
+}
+
+func TestFileKindsNeverOpenPipes(t *testing.T) {
+	fsys := &openRecordingFS{MapFS: fstest.MapFS{
+		"fifo":   {Data: []byte("#!/usr/bin/python3\n"), Mode: fs.ModeNamedPipe},
+		"script": {Data: []byte("#!/usr/bin/python3\n")},
+	}}
+	options := newTestOptions(fsys)
+	options.ListAll = true
+	options.Types = []FileType{TypeFile, TypePipe}
+	options.IncludeKinds = fileKindsForTest("py")
+
+	got := resultPaths(searchForTest(t, options), ResultEntry)
+	if want := []string{"script"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for _, name := range fsys.opened {
+		if name == "fifo" {
+			t.Errorf("opened the pipe")
+		}
+	}
+}
+
+/**************************************************************************/
@@ -0,0 +1,115 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+/**************************************************************************/
+
+// File types.
+
+// FileType is a kind of dir entry, named by the letter used by find -type.
+type FileType byte
+
+const (
+	TypeFile        FileType = 'f'
+	TypeDir         FileType = 'd'
+	TypeLink        FileType = 'l'
+	TypePipe        FileType = 'p'
+	TypeSocket      FileType = 's'
+	TypeBlockDevice FileType = 'b'
+	TypeCharDevice  FileType = 'c'
+)
+
+// ParseFileTypes reads file type letters such as "fd" or "f,l".
+func ParseFileTypes(str string) ([]FileType, error) {
+	var types []FileType
+	for _, char := range str {
+		switch {
+		case (char == ',') || (char == ';') || (char == ' '):
+		case (char < 128) && isKnownFileType(FileType(char)):
+			types = append(types, FileType(char))
+		default:
+			return nil, fmt.Errorf("unknown file type %q", char)
+		}
+	}
+	return types, nil
+}
+
+func isKnownFileType(fileType FileType) bool {
+	switch fileType {
+	case TypeFile, TypeDir, TypeLink, TypePipe, TypeSocket, TypeBlockDevice, TypeCharDevice:
+		return true
+	}
+	return false
+}
+
+func fileTypeOf(mode fs.FileMode) FileType {
+	switch {
+	case mode.IsDir():
+		return TypeDir
+	case (mode & fs.ModeSymlink) != 0:
+		return TypeLink
+	case (mode & fs.ModeNamedPipe) != 0:
+		return TypePipe
+	case (mode & fs.ModeSocket) != 0:
+		return TypeSocket
+	case (mode & fs.ModeCharDevice) != 0:
+		return TypeCharDevice
+	case (mode & fs.ModeDevice) != 0:
+		return TypeBlockDevice
+	default:
+		return TypeFile
+	}
+}
+
+// Pipes, sockets and devices can block or never end when read, so they are
+// only ever listed by name, and only when their type is asked for.
+func isSpecialFileType(fileType FileType) bool {
+	return (fileType != TypeFile) && (fileType != TypeDir) && (fileType != TypeLink)
+}
+
+func (run *searchRun) isWantedType(fileType FileType) bool {
+	if len(run.options.Types) == 0 {
+		return !isSpecialFileType(fileType)
+	}
+	for _, t := range run.options.Types {
+		if t == fileType {
+			return true
+		}
+	}
+	return false
+}
+
+// Links are listed as they are when their type is wanted and they are not
+// followed.
+func (run *searchRun) isWantedLink() bool {
+	return !run.options.FollowLinks && (len(run.options.Types) > 0) && run.isWantedType(TypeLink)
+}
+
+/**************************************************************************/
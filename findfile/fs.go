@@ -0,0 +1,110 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+/**************************************************************************/
+
+// File systems.
+
+// LstatFS is a file system that can describe a symbolic link without
+// following it. Searchers use it to avoid following links when the file
+// system given in Options.FS supports it.
+type LstatFS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// ReadLinkFS is a file system that can tell the target of a symbolic link.
+// Searchers use it to show where a broken link points to.
+type ReadLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// OSFS returns the local file system, which is what a Searcher reads when
+// Options.FS is nil. Unlike os.DirFS, it accepts any path the os package
+// accepts, including absolute paths, ".." and native path separators.
+func OSFS() fs.FS {
+	return osFS{}
+}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFS) ReadLink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// Paths on the local file system use the native separator, while paths in
+// any other fs.FS are always slash-separated.
+func joinPathFuncFor(fsys fs.FS) func(elem ...string) string {
+	if _, ok := fsys.(osFS); ok {
+		return filepath.Join
+	}
+	return path.Join
+}
+
+// Describes a dir entry without following it if it is a link. Without
+// LstatFS, the file system is trusted to describe its links as links.
+func lstat(fsys fs.FS, name string, entry fs.DirEntry) (fs.FileInfo, error) {
+	if lstatFS, ok := fsys.(LstatFS); ok {
+		return lstatFS.Lstat(name)
+	}
+	return entry.Info()
+}
+
+// Returns an empty string if the target cannot be read.
+func readLink(fsys fs.FS, name string) string {
+	if readLinkFS, ok := fsys.(ReadLinkFS); ok {
+		if target, err := readLinkFS.ReadLink(name); err == nil {
+			return target
+		}
+	}
+	return ""
+}
+
+/**************************************************************************/
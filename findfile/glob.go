@@ -0,0 +1,311 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/**************************************************************************/
+
+// Include and exclude filters.
+
+// A globFilter is one pattern given in Options.IncludeFiles and the like.
+// Patterns without a slash match the base name, as with filepath.Match,
+// while patterns with a slash match the whole path relative to the
+// starting dir. A leading '!' negates the pattern.
+type globFilter struct {
+	negate bool
+
+	// Brace-expanded base name patterns, or nil for a path pattern.
+	baseNameGlobs []string
+
+	// For path patterns, the whole path and the leading dirs it can match.
+	pathRegex      *regexp.Regexp
+	pathDirRegexes []*regexp.Regexp
+}
+
+// A globFilterList is evaluated like an ignore file: the last pattern
+// matching a name decides, so "*.go; !*_test.go" matches Go files other
+// than tests. A list of negated patterns only matches everything else.
+type globFilterList struct {
+	filters      []globFilter
+	onlyNegated  bool
+	hasPathGlobs bool
+}
+
+func newGlobFilterList(globs []string) (*globFilterList, error) {
+	list := &globFilterList{onlyNegated: len(globs) > 0}
+	for _, glob := range globs {
+		filter, err := newGlobFilter(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %v", glob, err)
+		}
+		list.filters = append(list.filters, filter)
+		list.onlyNegated = list.onlyNegated && filter.negate
+		list.hasPathGlobs = list.hasPathGlobs || (filter.pathRegex != nil)
+	}
+	return list, nil
+}
+
+func newGlobFilter(glob string) (globFilter, error) {
+	var filter globFilter
+	if strings.HasPrefix(glob, "!") {
+		filter.negate = true
+		glob = glob[1:]
+	}
+
+	globs, err := expandBraces(glob)
+	if err != nil {
+		return filter, err
+	}
+
+	if !strings.Contains(glob, "/") {
+		for _, g := range globs {
+			if _, err := filepath.Match(g, ""); err != nil {
+				return filter, err
+			}
+		}
+		filter.baseNameGlobs = globs
+		return filter, nil
+	}
+
+	// Path patterns are always relative to the starting dir.
+	for i := range globs {
+		globs[i] = strings.Trim(globs[i], "/")
+	}
+
+	filter.pathRegex, err = compileGlobs(globs)
+	if err != nil {
+		return filter, err
+	}
+
+	// A dir leading to a possible match, e.g. "src" for "src/*/test",
+	// must also match so that include filters let the search reach it.
+	maxSegments := 0
+	for _, g := range globs {
+		if n := strings.Count(g, "/") + 1; n > maxSegments {
+			maxSegments = n
+		}
+	}
+	for n := 1; n < maxSegments; n++ {
+		var prefixes []string
+		for _, g := range globs {
+			segments := strings.Split(g, "/")
+			if n < len(segments) {
+				prefixes = append(prefixes, strings.Join(segments[:n], "/"))
+			}
+		}
+		regex, err := compileGlobs(prefixes)
+		if err != nil {
+			return filter, err
+		}
+		filter.pathDirRegexes = append(filter.pathDirRegexes, regex)
+	}
+	return filter, nil
+}
+
+func compileGlobs(globs []string) (*regexp.Regexp, error) {
+	exprs := make([]string, len(globs))
+	for i, g := range globs {
+		exprs[i] = globToRegex(g)
+	}
+	return regexp.Compile("^(?:" + strings.Join(exprs, "|") + ")$")
+}
+
+// Tells whether the filter matches the entry, given its base name and
+// its slash-separated path relative to the starting dir. Include filters
+// also match the dirs leading to a path pattern, so as to reach the dirs
+// and files it names.
+func (filter *globFilter) matches(baseName, relPath string, orLeadingDir bool) bool {
+	if filter.pathRegex == nil {
+		for _, glob := range filter.baseNameGlobs {
+			if matched, _ := filepath.Match(glob, baseName); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	if filter.pathRegex.MatchString(relPath) {
+		return true
+	}
+	if orLeadingDir && !filter.negate {
+		for _, regex := range filter.pathDirRegexes {
+			if regex.MatchString(relPath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (list *globFilterList) isEmpty() bool {
+	return len(list.filters) == 0
+}
+
+func (list *globFilterList) matches(baseName, relPath string, orLeadingDir bool) bool {
+	matched := list.onlyNegated
+	for i := range list.filters {
+		filter := &list.filters[i]
+		if filter.matches(baseName, relPath, orLeadingDir) {
+			matched = !filter.negate
+		}
+	}
+	return matched
+}
+
+/**************************************************************************/
+
+// Glob syntax.
+
+// Expands "{a,b}" alternatives into separate patterns, so that "*.{ts,tsx}"
+// gives "*.ts" and "*.tsx". Braces can be nested and escaped with '\'.
+func expandBraces(glob string) ([]string, error) {
+	start := -1
+	depth := 0
+	var commas []int
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				start = i
+				commas = commas[:0]
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				return nil, fmt.Errorf("unmatched '}'")
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			prefix, suffix := glob[:start], glob[i+1:]
+			bounds := append(append([]int{start}, commas...), i)
+			var expanded []string
+			for j := 0; j+1 < len(bounds); j++ {
+				alternatives, err := expandBraces(prefix + glob[bounds[j]+1:bounds[j+1]] + suffix)
+				if err != nil {
+					return nil, err
+				}
+				expanded = append(expanded, alternatives...)
+			}
+			return expanded, nil
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unmatched '{'")
+	}
+	return []string{glob}, nil
+}
+
+// Converts a slash-separated glob pattern into a regex. "**" matches any
+// number of dirs when it is a whole path segment, and '*' and '?' never
+// match a slash.
+func globToRegex(glob string) string {
+	var expr strings.Builder
+	segments := strings.Split(glob, "/")
+
+	for pos, segment := range segments {
+		isLast := (pos == len(segments)-1)
+
+		if segment == "**" {
+			if isLast {
+				expr.WriteString(".*")
+			} else {
+				expr.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+
+		expr.WriteString(globSegmentToRegex(segment))
+		if !isLast {
+			expr.WriteString("/")
+		}
+	}
+	return expr.String()
+}
+
+func globSegmentToRegex(segment string) string {
+	var expr strings.Builder
+	chars := []rune(segment)
+
+	for i := 0; i < len(chars); i++ {
+		char := chars[i]
+		switch char {
+		case '*':
+			expr.WriteString("[^/]*")
+			for i+1 < len(chars) && chars[i+1] == '*' {
+				i++
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(chars) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(string(chars[i])))
+			}
+		case '[':
+			end := i + 1
+			if end < len(chars) && (chars[end] == '!' || chars[end] == '^') {
+				end++
+			}
+			if end < len(chars) && chars[end] == ']' {
+				end++
+			}
+			for end < len(chars) && chars[end] != ']' {
+				end++
+			}
+			if end >= len(chars) {
+				// No closing bracket, so treat it as a literal.
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := string(chars[i+1 : end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			class = strings.Replace(class, `\`, `\\`, -1)
+			expr.WriteString("[" + class + "]")
+			i = end
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	return expr.String()
+}
+
+/**************************************************************************/
@@ -0,0 +1,86 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"strings"
+	"testing"
+)
+
+/**************************************************************************/
+
+// Glob filters.
+
+func TestGlobFilterList(t *testing.T) {
+	tests := []struct {
+		globs        []string
+		relPath      string
+		orLeadingDir bool
+		want         bool
+	}{
+		{[]string{"*.go"}, "src/a.go", false, true},
+		{[]string{"*.go"}, "src/a.txt", false, false},
+		{[]string{"*.go", "!*_test.go"}, "a_test.go", false, false},
+		{[]string{"!*_test.go"}, "a.go", false, true},
+		{[]string{"!*_test.go"}, "a_test.go", false, false},
+		{[]string{"!*_test.go", "*_test.go"}, "a_test.go", false, true},
+		{[]string{"*.{ts,tsx}"}, "a.tsx", false, true},
+		{[]string{"*.{ts,tsx}"}, "a.js", false, false},
+		{[]string{"src/*.go"}, "src/a.go", false, true},
+		{[]string{"src/*.go"}, "src/x/a.go", false, false},
+		{[]string{"src/*.go"}, "lib/src/a.go", false, false},
+		{[]string{"/src/*.go"}, "src/a.go", false, true},
+		{[]string{"src/**/*.go"}, "src/a.go", false, true},
+		{[]string{"src/**/*.go"}, "src/x/y/a.go", false, true},
+		{[]string{"src/**/*.go"}, "lib/a.go", false, false},
+		{[]string{"**/test"}, "test", false, true},
+		{[]string{"**/test"}, "a/b/test", false, true},
+		{[]string{"src/*/test"}, "src", true, true},
+		{[]string{"src/*/test"}, "src/x", true, true},
+		{[]string{"src/*/test"}, "src/x", false, false},
+		{[]string{"src/*/test"}, "lib", true, false},
+	}
+	for _, test := range tests {
+		list, err := newGlobFilterList(test.globs)
+		if err != nil {
+			t.Fatalf("%q: %v", test.globs, err)
+		}
+		baseName := test.relPath[strings.LastIndex(test.relPath, "/")+1:]
+		if got := list.matches(baseName, test.relPath, test.orLeadingDir); got != test.want {
+			t.Errorf("%q matching %q (leading dir %v): got %v, want %v",
+				test.globs, test.relPath, test.orLeadingDir, got, test.want)
+		}
+	}
+}
+
+func TestGlobFilterListErrors(t *testing.T) {
+	for _, glob := range []string{"*.{go", "*.go}", "[a-"} {
+		if _, err := newGlobFilterList([]string{glob}); err == nil {
+			t.Errorf("%q: no error", glob)
+		}
+	}
+}
+
+/**************************************************************************/
@@ -0,0 +1,260 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+/**************************************************************************/
+
+// Byte patterns.
+
+// Number of bytes in a hexdump row. The bytes reported around each match
+// run from the row before the match to the row after it.
+const HexRowSize = 16
+
+// Longest byte pattern accepted, so that files can be searched through a
+// window of bounded size.
+const maxBytePatternLength = 4096
+
+// Size of the window of a file searched at a time.
+const byteWindowSize = 1 << 20
+
+// A bytePattern matches a run of bytes, where -1 matches any byte.
+type bytePattern []int
+
+// Reads a pattern of hex bytes like "DE AD ?? EF", where "??" matches any
+// byte. Spaces between bytes are optional.
+func parseBytePattern(s string) (bytePattern, error) {
+	digits := strings.Join(strings.Fields(s), "")
+	if (len(digits) == 0) || (len(digits)%2 != 0) {
+		return nil, fmt.Errorf("invalid byte pattern %q: expecting pairs of hex digits or ??", s)
+	}
+
+	pattern := make(bytePattern, 0, len(digits)/2)
+	hasByte := false
+	for pos := 0; pos < len(digits); pos += 2 {
+		pair := digits[pos : pos+2]
+		if pair == "??" {
+			pattern = append(pattern, -1)
+			continue
+		}
+		high, highOK := hexDigitValue(pair[0])
+		low, lowOK := hexDigitValue(pair[1])
+		if !highOK || !lowOK {
+			return nil, fmt.Errorf("invalid byte pattern %q: bad hex byte %q", s, pair)
+		}
+		pattern = append(pattern, high<<4|low)
+		hasByte = true
+	}
+
+	if !hasByte {
+		return nil, fmt.Errorf("invalid byte pattern %q: only wildcards", s)
+	}
+	if len(pattern) > maxBytePatternLength {
+		return nil, fmt.Errorf("invalid byte pattern %q: longer than %v bytes", s, maxBytePatternLength)
+	}
+	return pattern, nil
+}
+
+func hexDigitValue(c byte) (int, bool) {
+	switch {
+	case (c >= '0') && (c <= '9'):
+		return int(c - '0'), true
+	case (c >= 'a') && (c <= 'f'):
+		return int(c-'a') + 10, true
+	case (c >= 'A') && (c <= 'F'):
+		return int(c-'A') + 10, true
+	}
+	return 0, false
+}
+
+// Returns the index of the first match in data at or after from, or -1.
+func (p bytePattern) index(data []byte, from int) int {
+	// Look for the first byte that is not a wildcard, then check the rest.
+	anchor := 0
+	for p[anchor] < 0 {
+		anchor++
+	}
+
+	for pos := from; pos+len(p) <= len(data); pos++ {
+		i := bytes.IndexByte(data[pos+anchor:len(data)-len(p)+anchor+1], byte(p[anchor]))
+		if i < 0 {
+			return -1
+		}
+		pos += i
+		if p.matchesAt(data[pos:]) {
+			return pos
+		}
+	}
+	return -1
+}
+
+func (p bytePattern) matchesAt(data []byte) bool {
+	for pos, b := range p {
+		if (b >= 0) && (data[pos] != byte(b)) {
+			return false
+		}
+	}
+	return true
+}
+
+/**************************************************************************/
+
+// Searching raw bytes.
+
+// Searches the raw bytes of a file for the byte patterns, reporting each
+// match with the bytes around it.
+func (w *searchWorker) searchFileBytes(path string, file io.Reader) error {
+	patterns := w.matcher.bytePatterns
+	maxLength := 0
+	for _, pattern := range patterns {
+		if len(pattern) > maxLength {
+			maxLength = len(pattern)
+		}
+	}
+
+	var buffer []byte
+	var bufferOffset, numBytesRead int64
+	var readErr error
+	defer func() {
+		atomic.AddInt64(&w.numBytesRead, numBytesRead)
+
+		// Read errors end the file early.
+		if readErr != nil {
+			w.reportError(path, readErr)
+		}
+	}()
+
+	numMatches := 0
+	searchFrom := 0
+	isFileEnded := false
+	chunk := make([]byte, 64*1024)
+	for !w.isCancelled() {
+		for !isFileEnded && (len(buffer) < byteWindowSize) {
+			n, err := file.Read(chunk)
+			buffer = append(buffer, chunk[:n]...)
+			numBytesRead += int64(n)
+			if err == io.EOF {
+				isFileEnded = true
+			} else if err != nil {
+				readErr = err
+				isFileEnded = true
+			}
+		}
+
+		// Matches, and the row after them, must fit within the window.
+		limit := len(buffer)
+		if !isFileEnded {
+			limit -= maxLength + 2*HexRowSize
+		}
+
+		// The next match of each pattern is remembered until passed, so that
+		// the patterns matching rarely are not searched for again each time.
+		// It is -2 until searched for, and -1 when there are no more.
+		nextMatches := make([]int, len(patterns))
+		for pos := range nextMatches {
+			nextMatches[pos] = -2
+		}
+		for {
+			begin, end := -1, 0
+			for pos, pattern := range patterns {
+				if (nextMatches[pos] == -2) || ((nextMatches[pos] >= 0) && (nextMatches[pos] < searchFrom)) {
+					nextMatches[pos] = pattern.index(buffer, searchFrom)
+				}
+				if i := nextMatches[pos]; (i >= 0) && ((begin < 0) || (i < begin)) {
+					begin, end = i, i+len(pattern)
+				}
+			}
+			if (begin < 0) || (begin >= limit) {
+				break
+			}
+			searchFrom = end
+			numMatches++
+
+			if w.options.CountOnly {
+				continue
+			}
+			if err := w.report(newBytesResult(path, buffer, bufferOffset, begin, end)); err != nil {
+				return err
+			}
+			if w.isCancelled() {
+				return nil
+			}
+		}
+
+		if isFileEnded {
+			break
+		}
+
+		// Keep the rows before the next matches.
+		if searchFrom < limit {
+			searchFrom = limit
+		}
+		cut := limit - 2*HexRowSize
+		if cut < 0 {
+			cut = 0
+		}
+		buffer = append(buffer[:0], buffer[cut:]...)
+		bufferOffset += int64(cut)
+		searchFrom -= cut
+	}
+
+	if w.options.CountOnly && (numMatches > 0) && !w.isCancelled() {
+		return w.report(&Result{Kind: ResultFile, Path: path, NumMatches: numMatches})
+	}
+	return nil
+}
+
+// Returns the result of a match, with the whole rows around it.
+func newBytesResult(path string, buffer []byte, bufferOffset int64, begin, end int) *Result {
+	offset := bufferOffset + int64(begin)
+	rowBegin := offset - offset%HexRowSize - HexRowSize
+	textBegin := int(rowBegin - bufferOffset)
+	if textBegin < 0 {
+		textBegin = 0
+	}
+	endOffset := bufferOffset + int64(end)
+	rowEnd := endOffset + (HexRowSize-endOffset%HexRowSize)%HexRowSize + HexRowSize
+	textEnd := int(rowEnd - bufferOffset)
+	if textEnd > len(buffer) {
+		textEnd = len(buffer)
+	}
+
+	return &Result{
+		Kind:   ResultBytes,
+		Path:   path,
+		Text:   string(buffer[textBegin:textEnd]),
+		Spans:  []Span{{Begin: begin - textBegin, End: end - textBegin}},
+		Offset: offset,
+	}
+}
+
+/**************************************************************************/
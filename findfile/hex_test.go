@@ -0,0 +1,101 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Byte patterns.
+
+func TestParseBytePattern(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bytePattern
+	}{
+		{"DE AD ?? EF", bytePattern{0xde, 0xad, -1, 0xef}},
+		{"deadbeef", bytePattern{0xde, 0xad, 0xbe, 0xef}},
+		{" 00  ff ", bytePattern{0x00, 0xff}},
+		{"??41??", bytePattern{-1, 0x41, -1}},
+	}
+	for _, test := range tests {
+		got, err := parseBytePattern(test.s)
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.s, got, test.want)
+		}
+	}
+
+	for _, s := range []string{"", "DE A", "GG", "?? ??", "D?"} {
+		if _, err := parseBytePattern(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+}
+
+func TestSearchBytePatterns(t *testing.T) {
+	// Matches at the start, around the end of the first window, and at the
+	// end of the file.
+	data := make([]byte, 2*byteWindowSize+100)
+	offsets := []int64{
+		0,
+		byteWindowSize - 4 - 2*HexRowSize,
+		byteWindowSize - 2,
+		int64(len(data)) - 4,
+	}
+	for i, offset := range offsets {
+		copy(data[offset:], []byte{0xde, 0xad, byte(i), 0xef})
+	}
+	fsys := fstest.MapFS{"data.bin": {Data: data}}
+	options := newTestOptions(fsys)
+	options.SearchStrings = []string{"DE AD ?? EF"}
+	options.HexPatterns = true
+
+	var got []int64
+	for _, result := range searchForTest(t, options) {
+		if result.Kind != ResultBytes {
+			continue
+		}
+		got = append(got, result.Offset)
+		if len(result.Spans) != 1 {
+			t.Errorf("offset %v: %v spans", result.Offset, len(result.Spans))
+			continue
+		}
+		span := result.Spans[0]
+		if matched := result.Text[span.Begin:span.End]; (matched[0] != '\xde') || (matched[3] != '\xef') {
+			t.Errorf("offset %v: span of %q", result.Offset, matched)
+		}
+	}
+	if !reflect.DeepEqual(got, offsets) {
+		t.Errorf("got offsets %v, want %v", got, offsets)
+	}
+}
+
+/**************************************************************************/
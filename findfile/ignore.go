@@ -0,0 +1,324 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/**************************************************************************/
+
+// Constants.
+
+// DefaultIgnoreFileNames are the ignore files read from each dir when
+// Options.IgnoreFileNames is empty. Later files take priority.
+var DefaultIgnoreFileNames = []string{".gitignore", ".ignore", ".ffignore"}
+
+const (
+	gitDirName         = ".git"
+	gitInfoExcludePath = ".git/info/exclude"
+)
+
+/**************************************************************************/
+
+// Types.
+
+type (
+	// One line of an ignore file.
+	ignoreRule struct {
+		pattern    string
+		lineNumber int
+		negate     bool
+		dirOnly    bool
+		regex      *regexp.Regexp
+	}
+
+	// The rules read from one ignore file. Paths are matched relative to
+	// the dir holding the file. For files inside the search tree, that dir
+	// is dirRelPath, relative to the starting dir. For files above the
+	// starting dir, rootRelPath is the starting dir relative to theirs.
+	ignoreFile struct {
+		path        string
+		dirRelPath  string
+		rootRelPath string
+		rules       []ignoreRule
+	}
+
+	// Ignore files that apply to a dir, from the closest to the farthest.
+	ignoreList struct {
+		file   *ignoreFile
+		parent *ignoreList
+	}
+)
+
+/**************************************************************************/
+
+// Parsing ignore files.
+
+func parseIgnoreFile(data []byte, filePath string) *ignoreFile {
+	file := &ignoreFile{path: filePath}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		rule, ok := parseIgnoreRule(scanner.Text(), lineNumber)
+		if ok {
+			file.rules = append(file.rules, rule)
+		}
+	}
+	return file
+}
+
+func parseIgnoreRule(line string, lineNumber int) (ignoreRule, bool) {
+	rule := ignoreRule{lineNumber: lineNumber}
+
+	// Trailing spaces are ignored unless escaped with a backslash.
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || line[0] == '#' {
+		return rule, false
+	}
+	rule.pattern = line
+
+	// A backslash keeps a leading ! or # as it is.
+	if line[0] == '!' {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return rule, false
+	}
+
+	// A slash at the beginning or middle anchors the pattern to the dir of
+	// the ignore file. Otherwise the pattern matches a name at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored && !strings.HasPrefix(line, "**") {
+		expr.WriteString("(?:.*/)?")
+	}
+	expr.WriteString(globToRegex(line))
+	expr.WriteString("$")
+
+	regex, err := regexp.Compile(expr.String())
+	if err != nil {
+		return rule, false
+	}
+	rule.regex = regex
+	return rule, true
+}
+
+/**************************************************************************/
+
+// Loading ignore files.
+
+// Reads the ignore files found among the entries of a dir in the search
+// tree, and returns the list that applies to the entries.
+func (run *searchRun) loadIgnoreFiles(node *walkNode, entries []fs.DirEntry) *ignoreList {
+	ignores := node.ignores
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	// The repository's own exclude file has the lowest priority.
+	if names[gitDirName] {
+		ignores = run.loadIgnoreFile(ignores, run.joinPath(node.path, gitInfoExcludePath), node.relPath, "")
+	}
+
+	for _, name := range run.ignoreFileNames {
+		if names[name] {
+			ignores = run.loadIgnoreFile(ignores, run.joinPath(node.path, name), node.relPath, "")
+		}
+	}
+	return ignores
+}
+
+func (run *searchRun) loadIgnoreFile(ignores *ignoreList, filePath, dirRelPath, rootRelPath string) *ignoreList {
+	data, err := fs.ReadFile(run.fsys, filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			run.reportError(filePath, err)
+		}
+		return ignores
+	}
+	return addIgnoreFile(ignores, parseIgnoreFile(data, filePath), dirRelPath, rootRelPath)
+}
+
+func addIgnoreFile(ignores *ignoreList, file *ignoreFile, dirRelPath, rootRelPath string) *ignoreList {
+	if len(file.rules) == 0 {
+		return ignores
+	}
+	file.dirRelPath = dirRelPath
+	file.rootRelPath = rootRelPath
+	return &ignoreList{file: file, parent: ignores}
+}
+
+// Gets the ignore files that apply to a starting dir: the global ignore
+// file, then the ignore files in the dirs above the starting dir, up to the
+// top of its git repository.
+func (s *Searcher) loadStartingIgnoreFiles(dir string) *ignoreList {
+	// Only the local file system has dirs above the starting dir.
+	if _, ok := s.fsys.(osFS); !ok {
+		return s.loadGlobalIgnoreFile("")
+	}
+
+	startingDir, err := filepath.Abs(dir)
+	if err != nil {
+		return s.loadGlobalIgnoreFile("")
+	}
+
+	// Find the top of the git repository, if any.
+	var parentDirs []string
+	repoDir := ""
+	for dir := startingDir; ; {
+		if _, err := os.Stat(filepath.Join(dir, gitDirName)); err == nil {
+			repoDir = dir
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+		parentDirs = append(parentDirs, dir)
+	}
+
+	// The starting dir itself is handled during the search.
+	if repoDir == "" || repoDir == startingDir {
+		return s.loadGlobalIgnoreFile("")
+	}
+
+	// Like those of the ignore files above, the rules of the global ignore
+	// file are anchored at the top of the repository.
+	rel, err := filepath.Rel(repoDir, startingDir)
+	if err != nil {
+		return s.loadGlobalIgnoreFile("")
+	}
+	ignores := s.loadGlobalIgnoreFile(filepath.ToSlash(rel))
+
+	for i := len(parentDirs) - 1; i >= 0; i-- {
+		dir := parentDirs[i]
+		rel, err := filepath.Rel(dir, startingDir)
+		if err != nil {
+			continue
+		}
+		rootRelPath := filepath.ToSlash(rel)
+
+		names := s.ignoreFileNames
+		if dir == repoDir {
+			names = append([]string{gitInfoExcludePath}, names...)
+		}
+		for _, name := range names {
+			filePath := filepath.Join(dir, filepath.FromSlash(name))
+			data, err := os.ReadFile(filePath)
+			if err == nil {
+				ignores = addIgnoreFile(ignores, parseIgnoreFile(data, filePath), "", rootRelPath)
+			}
+		}
+	}
+	return ignores
+}
+
+// Reads the global ignore file, whose rules are anchored at the dir from
+// which rootRelPath leads to the starting dir.
+func (s *Searcher) loadGlobalIgnoreFile(rootRelPath string) *ignoreList {
+	if s.options.GlobalIgnoreFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.options.GlobalIgnoreFile)
+	if err != nil {
+		return nil
+	}
+	return addIgnoreFile(nil, parseIgnoreFile(data, s.options.GlobalIgnoreFile), "", rootRelPath)
+}
+
+/**************************************************************************/
+
+// Matching ignore rules.
+
+// Returns the rule deciding whether the dir or file at relPath, relative
+// to the starting dir, is ignored. Closer ignore files take priority, and
+// within a file, later rules take priority.
+func (ignores *ignoreList) match(relPath string, isDir bool) (*ignoreFile, *ignoreRule) {
+	for list := ignores; list != nil; list = list.parent {
+		file := list.file
+
+		var pathInFile string
+		if file.rootRelPath != "" {
+			pathInFile = path.Join(file.rootRelPath, relPath)
+		} else if file.dirRelPath == "" {
+			pathInFile = relPath
+		} else if strings.HasPrefix(relPath, file.dirRelPath+"/") {
+			pathInFile = relPath[len(file.dirRelPath)+1:]
+		} else {
+			continue
+		}
+
+		for i := len(file.rules) - 1; i >= 0; i-- {
+			rule := &file.rules[i]
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.regex.MatchString(pathInFile) {
+				return file, rule
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Returns whether the dir or file is ignored, and if so, the reason.
+func (ignores *ignoreList) isIgnored(relPath string, isDir bool) (bool, string) {
+	file, rule := ignores.match(relPath, isDir)
+	if rule == nil || rule.negate {
+		return false, ""
+	}
+	return true, fmt.Sprintf("%v:%v: %v", file.path, rule.lineNumber, rule.pattern)
+}
+
+/**************************************************************************/
@@ -0,0 +1,178 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Ignore files.
+
+// Returns the sorted paths of the files listed.
+func filePathsForTest(results []*Result) []string {
+	var files []*Result
+	for _, result := range results {
+		if !result.IsDir {
+			files = append(files, result)
+		}
+	}
+	return resultPaths(files, ResultEntry)
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore": {Data: []byte(
+			"*.log\n!keep.log\n/build\ndocs/**/draft.md\ntmp/\n")},
+		"a.log":              {},
+		"keep.log":           {},
+		"build/out.o":        {},
+		"src/build/b.go":     {},
+		"docs/draft.md":      {},
+		"docs/a/b/draft.md":  {},
+		"docs/final.md":      {},
+		"tmp/t.txt":          {},
+		"src/tmp":            {Data: []byte("a file, not a dir\n")},
+		"sub/.ignore":        {Data: []byte("!again.log\nlocal.txt\n")},
+		"sub/again.log":      {},
+		"sub/other.log":      {},
+		"sub/local.txt":      {},
+		"sub/deeper/x.log":   {},
+		"other/local.txt":    {},
+		"other/unignored.md": {},
+	}
+	options := newTestOptions(fsys)
+	options.ListAll = true
+
+	got := filePathsForTest(searchForTest(t, options))
+	want := []string{
+		".gitignore",
+		"docs/final.md",
+		"keep.log",
+		"other/local.txt",
+		"other/unignored.md",
+		"src/build/b.go",
+		"src/tmp",
+		"sub/.ignore",
+		"sub/again.log",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIgnoreFilesReported(t *testing.T) {
+	fsys := fstest.MapFS{
+		".ffignore":   {Data: []byte("# comment\n\nbuild/\n")},
+		"build/out.o": {},
+		"main.go":     {},
+	}
+	options := newTestOptions(fsys)
+	options.ListAll = true
+	options.ReportIgnored = true
+
+	got := resultPaths(searchForTest(t, options), ResultIgnored)
+	want := []string{"build"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIgnoreFilesNotUsed(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore": {Data: []byte("*.log\n")},
+		"a.log":      {},
+	}
+	options := newTestOptions(fsys)
+	options.ListAll = true
+	options.UseIgnoreFiles = false
+
+	got := filePathsForTest(searchForTest(t, options))
+	want := []string{".gitignore", "a.log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIgnoreFilesEscapes(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore": {Data: []byte("*.txt\n\\!keep.txt\n\\#notes.txt\n!\\!keep.txt\n")},
+		"!keep.txt":  {},
+		"#notes.txt": {},
+		"keep.txt":   {},
+		"other.txt":  {},
+	}
+	options := newTestOptions(fsys)
+	options.ListAll = true
+
+	got := filePathsForTest(searchForTest(t, options))
+	want := []string{"!keep.txt", ".gitignore"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// The global ignore file applies from the top of the repository, even
+// when the search starts below it.
+func TestGlobalIgnoreFileAnchoredAtRepository(t *testing.T) {
+	repoDir := t.TempDir()
+	globalIgnoreFile := filepath.Join(t.TempDir(), "ignore")
+	files := map[string]string{
+		globalIgnoreFile:                             "/sub/skipped.txt\n/kept.txt\n",
+		filepath.Join(repoDir, ".git", "HEAD"):       "",
+		filepath.Join(repoDir, "sub", "skipped.txt"): "",
+		filepath.Join(repoDir, "sub", "kept.txt"):    "",
+	}
+	for name, text := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	startingDir := filepath.Join(repoDir, "sub")
+	options := DefaultOptions()
+	options.Roots = []string{startingDir}
+	options.ListAll = true
+	options.GlobalIgnoreFile = globalIgnoreFile
+
+	var got []string
+	for _, path := range filePathsForTest(searchForTest(t, options)) {
+		got = append(got, filepath.Base(path))
+	}
+	want := []string{"kept.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+/**************************************************************************/
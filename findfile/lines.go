@@ -0,0 +1,458 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+/**************************************************************************/
+
+// Long lines.
+
+// DefaultMaxLineLength is the number of bytes in a line above which
+// Options.LongLines applies, when Options.MaxLineLength is 0.
+const DefaultMaxLineLength = 1 << 20
+
+// Size of the buffer that lines are read through.
+const lineReaderBufferSize = 64 * 1024
+
+// LongLinePolicy tells what to do with the lines longer than
+// Options.MaxLineLength, such as in minified files.
+type LongLinePolicy int
+
+const (
+	// Search long lines in chunks of up to MaxLineLength bytes, each
+	// reported with the line number and columns of the whole line.
+	// Matches across two chunks are not found.
+	LongLinesChunk LongLinePolicy = iota
+
+	// Only search the first MaxLineLength bytes of long lines.
+	LongLinesTruncate
+
+	// Don't search long lines at all.
+	LongLinesSkip
+)
+
+var longLinePolicyNames = map[string]LongLinePolicy{
+	"chunk":    LongLinesChunk,
+	"truncate": LongLinesTruncate,
+	"skip":     LongLinesSkip,
+}
+
+// ParseLongLinePolicy reads a policy name: chunk, truncate or skip.
+func ParseLongLinePolicy(name string) (LongLinePolicy, error) {
+	policy, ok := longLinePolicyNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown long line policy %q", name)
+	}
+	return policy, nil
+}
+
+/**************************************************************************/
+
+// Records.
+
+// RecordSeparator tells how file contents are split into the records that
+// are matched against the search strings, instead of lines.
+type RecordSeparator int
+
+const (
+	// Each line is a record.
+	RecordsLines RecordSeparator = iota
+
+	// Records are paragraphs, separated by blank lines, which are not part
+	// of any record.
+	RecordsParagraphs
+
+	// Records are separated by NUL bytes, like the output of find -print0.
+	RecordsNUL
+
+	// Each line matching Options.RecordRegex starts a new record, such as
+	// the timestamped first line of a log entry.
+	RecordsRegex
+)
+
+var recordSeparatorNames = map[string]RecordSeparator{
+	"line":      RecordsLines,
+	"paragraph": RecordsParagraphs,
+	"nul":       RecordsNUL,
+	"regex":     RecordsRegex,
+}
+
+// ParseRecordSeparator reads a separator name: line, paragraph, nul or regex.
+func ParseRecordSeparator(name string) (RecordSeparator, error) {
+	separator, ok := recordSeparatorNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown record separator %q", name)
+	}
+	return separator, nil
+}
+
+/**************************************************************************/
+
+// Reading lines and maintaining context lines.
+
+// A line, or a chunk of a long line starting at the given 0-indexed column
+// and byte offset.
+type lineChunk struct {
+	Line
+	column int
+	offset int64
+}
+
+// A lineReader reads a file one line at a time, remembering the lines
+// before the current line and reading ahead the lines after it as needed
+// for context lines. Memory use is bounded by the longest line it keeps.
+// With records, a "line" is a whole record, possibly of several lines.
+type lineReader struct {
+	reader          *bufio.Reader
+	maxLineLength   int
+	longLines       LongLinePolicy
+	records         RecordSeparator
+	recordRegex     *regexp.Regexp
+	delimiter       byte
+	numContextLines int
+	lineNumber      int
+	line            string
+	column          int
+	offset          int64
+	preContextLines []Line
+	readAheadLines  []lineChunk
+	numBytesRead    int64
+
+	// The first error other than io.EOF, which ends the file early.
+	err error
+
+	// Bytes of the line being read that were not returned yet.
+	pending      []byte
+	isLineEnded  bool
+	isFileEnded  bool
+	numLinesRead int
+
+	// Whether chunks of the line being read were already returned, and
+	// how many runes they had.
+	isInLongLine bool
+	nextColumn   int
+
+	// Line number of the line being read, and with NUL records, the number
+	// of line ends read before it and so far.
+	lineNumberRead    int
+	numLineEndsBefore int
+	numLineEnds       int
+
+	// A line read ahead that starts the next record.
+	heldLine    lineChunk
+	hasHeldLine bool
+
+	// With Options.Strings, the strings extracted from the file are read
+	// instead of its lines.
+	strings *stringExtractor
+}
+
+func newLineReader(reader io.Reader, options *Options, recordRegex *regexp.Regexp) *lineReader {
+	lr := &lineReader{
+		reader:          bufio.NewReaderSize(reader, lineReaderBufferSize),
+		maxLineLength:   options.MaxLineLength,
+		longLines:       options.LongLines,
+		records:         options.Records,
+		recordRegex:     recordRegex,
+		delimiter:       '\n',
+		numContextLines: options.ContextLines,
+	}
+	if options.Records == RecordsNUL {
+		lr.delimiter = 0
+	}
+	if options.Strings {
+		lr.strings = newStringExtractor(options.MinStringLength)
+	}
+	return lr
+}
+
+// Advances to the next line, returning false at the end of the file.
+func (lr *lineReader) next() bool {
+	if lr.numContextLines > 0 && lr.lineNumber > 0 {
+		if len(lr.preContextLines) == lr.numContextLines {
+			copy(lr.preContextLines, lr.preContextLines[1:])
+			lr.preContextLines = lr.preContextLines[:len(lr.preContextLines)-1]
+		}
+		lr.preContextLines = append(lr.preContextLines, Line{Number: lr.lineNumber, Text: lr.line})
+	}
+
+	// Read from post context lines first.
+	if len(lr.readAheadLines) > 0 {
+		lr.setLine(lr.readAheadLines[0])
+		lr.readAheadLines = lr.readAheadLines[1:]
+		return true
+	}
+
+	chunk, ok := lr.readRecord()
+	if !ok {
+		return false
+	}
+	lr.setLine(chunk)
+	return true
+}
+
+func (lr *lineReader) setLine(chunk lineChunk) {
+	lr.lineNumber, lr.line, lr.column, lr.offset = chunk.Number, chunk.Text, chunk.column, chunk.offset
+}
+
+// Returns a copy of the context lines before the current line.
+func (lr *lineReader) before() []Line {
+	if len(lr.preContextLines) == 0 {
+		return nil
+	}
+	return append([]Line(nil), lr.preContextLines...)
+}
+
+// Returns a copy of the context lines after the current line, reading them
+// ahead from the file if needed.
+func (lr *lineReader) after() []Line {
+	if lr.numContextLines == 0 {
+		return nil
+	}
+
+	for len(lr.readAheadLines) < lr.numContextLines {
+		chunk, ok := lr.readRecord()
+		if !ok {
+			break
+		}
+		lr.readAheadLines = append(lr.readAheadLines, chunk)
+	}
+
+	if len(lr.readAheadLines) == 0 {
+		return nil
+	}
+	lines := make([]Line, len(lr.readAheadLines))
+	for pos, chunk := range lr.readAheadLines {
+		lines[pos] = chunk.Line
+	}
+	return lines
+}
+
+// Reads the next line from the file, or the next chunk of a long line.
+func (lr *lineReader) readLine() (lineChunk, bool) {
+	// Most lines are read whole from the buffer, without copying them to
+	// the pending bytes first.
+	if (len(lr.pending) == 0) && !lr.isInLongLine && !lr.isFileEnded && (lr.err == nil) {
+		data, err := lr.reader.ReadSlice(lr.delimiter)
+		if (err == nil) && (len(data) <= lr.maxLineLength) {
+			lr.numBytesRead += int64(len(data))
+			lr.numLinesRead++
+			lr.nextColumn = 0
+			lr.lineNumberRead = lr.numLinesRead
+			if lr.delimiter != '\n' {
+				lr.lineNumberRead = lr.numLineEndsBefore + 1
+				lr.numLineEnds += bytes.Count(data, []byte{'\n'})
+			}
+			chunk := lineChunk{
+				Line:   Line{Number: lr.lineNumberRead, Text: string(lr.trimDelimiter(data))},
+				offset: lr.numBytesRead - int64(len(data)),
+			}
+			lr.endLine()
+			return chunk, true
+		}
+		lr.addRead(data, err, true)
+	}
+
+	// Read until the end of the line, or until there is more than a chunk.
+	for !lr.isLineEnded && (len(lr.pending) <= lr.maxLineLength) {
+		lr.readMore(true)
+	}
+	if (len(lr.pending) == 0) && !lr.isInLongLine && (lr.isFileEnded || (lr.err != nil)) {
+		return lineChunk{}, false
+	}
+
+	if !lr.isInLongLine {
+		lr.numLinesRead++
+		lr.nextColumn = 0
+		lr.lineNumberRead = lr.numLinesRead
+		if lr.delimiter != '\n' {
+			lr.lineNumberRead = lr.numLineEndsBefore + 1
+		}
+	}
+	chunk := lineChunk{
+		Line:   Line{Number: lr.lineNumberRead},
+		column: lr.nextColumn,
+		offset: lr.numBytesRead - int64(len(lr.pending)),
+	}
+
+	if len(lr.pending) <= lr.maxLineLength {
+		chunk.Text = string(lr.trimDelimiter(lr.pending))
+		lr.endLine()
+		return chunk, true
+	}
+
+	// Don't cut a character in two.
+	end := lr.maxLineLength
+	for (end > 0) && !utf8.RuneStart(lr.pending[end]) {
+		end--
+	}
+	if end == 0 {
+		end = lr.maxLineLength
+	}
+
+	switch lr.longLines {
+	case LongLinesChunk:
+		chunk.Text = string(lr.pending[:end])
+		lr.pending = append(lr.pending[:0], lr.pending[end:]...)
+		lr.isInLongLine = true
+		lr.nextColumn += utf8.RuneCountInString(chunk.Text)
+		return chunk, true
+
+	case LongLinesTruncate:
+		chunk.Text = string(lr.pending[:end])
+	}
+
+	// Drop the rest of the line.
+	lr.pending = lr.pending[:0]
+	for !lr.isLineEnded {
+		lr.readMore(false)
+	}
+	lr.endLine()
+	return chunk, true
+}
+
+// Reads up to the end of the line or of the buffer, keeping what was read
+// in the pending bytes if asked to.
+func (lr *lineReader) readMore(keep bool) {
+	if lr.isFileEnded || (lr.err != nil) {
+		lr.isLineEnded = true
+		return
+	}
+
+	data, err := lr.reader.ReadSlice(lr.delimiter)
+	lr.addRead(data, err, keep)
+}
+
+// Counts the bytes read, and keeps them in the pending bytes if asked to.
+func (lr *lineReader) addRead(data []byte, err error, keep bool) {
+	lr.numBytesRead += int64(len(data))
+	if lr.delimiter != '\n' {
+		lr.numLineEnds += bytes.Count(data, []byte{'\n'})
+	}
+	if keep {
+		lr.pending = append(lr.pending, data...)
+	}
+
+	switch err {
+	case nil:
+		lr.isLineEnded = true
+	case bufio.ErrBufferFull:
+	case io.EOF:
+		lr.isFileEnded = true
+		lr.isLineEnded = true
+	default:
+		lr.err = err
+		lr.isLineEnded = true
+	}
+}
+
+func (lr *lineReader) endLine() {
+	lr.pending = lr.pending[:0]
+	lr.isLineEnded = false
+	lr.isInLongLine = false
+	lr.numLineEndsBefore = lr.numLineEnds
+}
+
+func (lr *lineReader) trimDelimiter(line []byte) []byte {
+	if lr.delimiter != '\n' {
+		return bytes.TrimSuffix(line, []byte{lr.delimiter})
+	}
+	return trimLineEnd(line)
+}
+
+// Reads the next record, which is the next line unless records are made of
+// several lines. Such records are cut into several when longer than
+// MaxLineLength, so that memory use stays bounded.
+func (lr *lineReader) readRecord() (lineChunk, bool) {
+	if lr.strings != nil {
+		return lr.readString()
+	}
+	if (lr.records != RecordsParagraphs) && (lr.records != RecordsRegex) {
+		return lr.readLine()
+	}
+
+	record, ok := lr.readLineOfRecord()
+	for ok && (lr.records == RecordsParagraphs) && isBlankLine(record.Text) {
+		record, ok = lr.readLineOfRecord()
+	}
+	if !ok {
+		return lineChunk{}, false
+	}
+
+	var text strings.Builder
+	text.WriteString(record.Text)
+	lastLineNumber := record.Number
+	for text.Len() < lr.maxLineLength {
+		chunk, ok := lr.readLineOfRecord()
+		if !ok {
+			break
+		}
+		if lr.records == RecordsParagraphs && isBlankLine(chunk.Text) {
+			break
+		}
+		if (lr.records == RecordsRegex) && (chunk.column == 0) && lr.recordRegex.MatchString(chunk.Text) {
+			lr.heldLine, lr.hasHeldLine = chunk, true
+			break
+		}
+
+		// The chunks of a long line are joined back.
+		if chunk.Number != lastLineNumber {
+			text.WriteByte('\n')
+		}
+		text.WriteString(chunk.Text)
+		lastLineNumber = chunk.Number
+	}
+	record.Text = text.String()
+	return record, true
+}
+
+func (lr *lineReader) readLineOfRecord() (lineChunk, bool) {
+	if lr.hasHeldLine {
+		lr.hasHeldLine = false
+		return lr.heldLine, true
+	}
+	return lr.readLine()
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// Drops the line end, like bufio.ScanLines.
+func trimLineEnd(line []byte) []byte {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r"))
+}
+
+/**************************************************************************/
@@ -0,0 +1,177 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+/**************************************************************************/
+
+// Reading lines.
+
+type testLine struct {
+	number int
+	column int
+	text   string
+}
+
+func newLineReaderForTest(text string, options Options, recordRegex *regexp.Regexp) *lineReader {
+	return newLineReader(strings.NewReader(text), &options, recordRegex)
+}
+
+func readLinesForTest(text string, options Options, recordRegex *regexp.Regexp) []testLine {
+	lr := newLineReaderForTest(text, options, recordRegex)
+	var lines []testLine
+	for lr.next() {
+		lines = append(lines, testLine{lr.lineNumber, lr.column, lr.line})
+	}
+	return lines
+}
+
+func TestLineReaderLines(t *testing.T) {
+	got := readLinesForTest("one\r\ntwo\n\nfour", Options{MaxLineLength: 100}, nil)
+	want := []testLine{{1, 0, "one"}, {2, 0, "two"}, {3, 0, ""}, {4, 0, "four"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLineReaderLongLines(t *testing.T) {
+	tests := []struct {
+		longLines LongLinePolicy
+		want      []testLine
+	}{
+		{LongLinesChunk, []testLine{{1, 0, "abcd"}, {1, 4, "efgh"}, {1, 8, "ij"}, {2, 0, "end"}}},
+		{LongLinesTruncate, []testLine{{1, 0, "abcd"}, {2, 0, "end"}}},
+		{LongLinesSkip, []testLine{{1, 0, ""}, {2, 0, "end"}}},
+	}
+	for _, test := range tests {
+		options := Options{MaxLineLength: 4, LongLines: test.longLines}
+		got := readLinesForTest("abcdefghij\nend\n", options, nil)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("long lines %v: got %v, want %v", test.longLines, got, test.want)
+		}
+	}
+}
+
+func TestLineReaderChunksKeepCharacters(t *testing.T) {
+	// Each character takes 3 bytes, so chunks of 4 bytes hold one.
+	options := Options{MaxLineLength: 4, LongLines: LongLinesChunk}
+	got := readLinesForTest("日本語\n", options, nil)
+	want := []testLine{{1, 0, "日"}, {1, 1, "本"}, {1, 2, "語"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLineReaderBoundedMemory(t *testing.T) {
+	const maxLineLength = 100
+	long := strings.Repeat("x", 4<<20)
+	for _, longLines := range []LongLinePolicy{LongLinesChunk, LongLinesTruncate, LongLinesSkip} {
+		options := Options{MaxLineLength: maxLineLength, LongLines: longLines}
+		lr := newLineReaderForTest(long+"\nend\n", options, nil)
+		numChunks, maxCap := 0, 0
+		lastLine := ""
+		for lr.next() {
+			if len(lr.line) > maxLineLength {
+				t.Fatalf("long lines %v: line of %v bytes", longLines, len(lr.line))
+			}
+			if cap(lr.pending) > maxCap {
+				maxCap = cap(lr.pending)
+			}
+			numChunks++
+			lastLine = lr.line
+		}
+		if lastLine != "end" {
+			t.Errorf("long lines %v: last line %q", longLines, lastLine)
+		}
+		if (longLines == LongLinesChunk) && (numChunks != (len(long)+maxLineLength-1)/maxLineLength+1) {
+			t.Errorf("long lines %v: %v chunks", longLines, numChunks)
+		}
+		if maxCap > 2*(maxLineLength+lineReaderBufferSize) {
+			t.Errorf("long lines %v: %v pending bytes kept", longLines, maxCap)
+		}
+	}
+}
+
+func TestLineReaderRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		records RecordSeparator
+		regex   string
+		text    string
+		want    []testLine
+	}{
+		{
+			"paragraphs", RecordsParagraphs, "",
+			"\none\ntwo\n\n \nthree\n",
+			[]testLine{{2, 0, "one\ntwo"}, {6, 0, "three"}},
+		},
+		{
+			"nul", RecordsNUL, "",
+			"one\ntwo\x00three\x00",
+			[]testLine{{1, 0, "one\ntwo"}, {2, 0, "three"}},
+		},
+		{
+			"regex", RecordsRegex, `^\d\d:\d\d `,
+			"10:00 start\n  detail\n10:01 next\n10:02 last\n  more\n",
+			[]testLine{{1, 0, "10:00 start\n  detail"}, {3, 0, "10:01 next"}, {4, 0, "10:02 last\n  more"}},
+		},
+	}
+	for _, test := range tests {
+		var recordRegex *regexp.Regexp
+		if test.regex != "" {
+			recordRegex = regexp.MustCompile(test.regex)
+		}
+		options := Options{MaxLineLength: 100, Records: test.records}
+		got := readLinesForTest(test.text, options, recordRegex)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLineReaderContextLines(t *testing.T) {
+	options := Options{MaxLineLength: 100, ContextLines: 1}
+	lr := newLineReaderForTest("a\nb\nc\nd\n", options, nil)
+	for lr.next() && (lr.line != "c") {
+	}
+	before, after := lr.before(), lr.after()
+	if !reflect.DeepEqual(before, []Line{{Number: 2, Text: "b"}}) {
+		t.Errorf("before: got %v", before)
+	}
+	if !reflect.DeepEqual(after, []Line{{Number: 4, Text: "d"}}) {
+		t.Errorf("after: got %v", after)
+	}
+	if !lr.next() || (lr.line != "d") {
+		t.Errorf("next after context: got %q", lr.line)
+	}
+}
+
+/**************************************************************************/
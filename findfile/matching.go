@@ -0,0 +1,514 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/**************************************************************************/
+
+// Types.
+
+// A matcher holds the prepared search strings. It is read-only once built,
+// so it can be shared by concurrent searches.
+type matcher struct {
+	ignoreCase              bool
+	wholeWord               bool
+	useRegex                bool
+	searchStringsToUse      [][]int
+	searchStringsToUseCount []int
+	searchStringsToContain  []string
+	searchStringsToExclude  [][]int
+	searchRegexesToUse      []*regexp.Regexp
+	searchRegexesToExclude  []*regexp.Regexp
+	multilineRegex          *regexp.Regexp
+	multilineRegexesToUse   []*regexp.Regexp
+	multilineExcludeRegex   *regexp.Regexp
+	bytePatterns            []bytePattern
+	fileIncludeFilters      *globFilterList
+	fileExcludeFilters      *globFilterList
+	dirIncludeFilters       *globFilterList
+	dirExcludeFilters       *globFilterList
+}
+
+// Scratch buffers used while matching one line at a time.
+type matchBuffers struct {
+	lineAsIntArray []int
+	runeOffsets    []int
+	spans          []Span
+}
+
+/**************************************************************************/
+
+// Prepare data used for matching.
+
+func newMatcher(options *Options) (*matcher, error) {
+	m := &matcher{
+		ignoreCase: options.IgnoreCase,
+		wholeWord:  options.WholeWord,
+		useRegex:   options.Regex,
+	}
+
+	var err error
+	if m.fileIncludeFilters, err = newGlobFilterList(trimAll(options.IncludeFiles)); err != nil {
+		return nil, err
+	}
+	if m.fileExcludeFilters, err = newGlobFilterList(trimAll(options.ExcludeFiles)); err != nil {
+		return nil, err
+	}
+	if m.dirIncludeFilters, err = newGlobFilterList(trimAll(options.IncludeDirs)); err != nil {
+		return nil, err
+	}
+	if m.dirExcludeFilters, err = newGlobFilterList(trimAll(options.ExcludeDirs)); err != nil {
+		return nil, err
+	}
+
+	if options.ListAll {
+		return m, nil
+	}
+
+	if options.HexPatterns {
+		for _, s := range options.SearchStrings {
+			pattern, err := parseBytePattern(s)
+			if err != nil {
+				return nil, err
+			}
+			m.bytePatterns = append(m.bytePatterns, pattern)
+		}
+		if len(m.bytePatterns) == 0 {
+			return nil, errors.New("no byte patterns given")
+		}
+		return m, nil
+	}
+
+	// Handle duplicate search strings. Regexes ignore case with the (?i)
+	// flag instead, so that matches are found in the line as it is.
+	ss := make([]string, len(options.SearchStrings))
+	for pos, s := range options.SearchStrings {
+		if m.ignoreCase && !m.useRegex {
+			s = strings.ToLower(s)
+		}
+		ss[pos] = s
+	}
+
+	// Form array without duplicate strings and count of each string.
+	stringsToUse := make([]string, 0, len(ss))
+
+	for i := 0; i < len(ss); i++ {
+		if ss[i] == "" {
+			continue
+		}
+		count := 1
+		for j := i + 1; j < len(ss); j++ {
+			if ss[i] == ss[j] {
+				count++
+				ss[j] = ""
+			}
+		}
+		stringsToUse = append(stringsToUse, ss[i])
+		m.searchStringsToUseCount = append(m.searchStringsToUseCount, count)
+	}
+
+	if len(stringsToUse) == 0 {
+		return nil, errors.New("no search strings given")
+	}
+
+	// Exclude strings.
+	stringsToExclude := trimAll(options.ExcludeStrings)
+	if options.Multiline {
+		if m.multilineRegex, err = compileMultilineRegex(options.SearchStrings, options, options.WholeWord); err != nil {
+			return nil, err
+		}
+		if len(stringsToUse) > 1 {
+			for _, s := range stringsToUse {
+				regex, err := compileMultilineRegex([]string{s}, options, options.WholeWord)
+				if err != nil {
+					return nil, err
+				}
+				m.multilineRegexesToUse = append(m.multilineRegexesToUse, regex)
+			}
+		}
+		wholeWord := options.WholeWord && options.Regex
+		if m.multilineExcludeRegex, err = compileMultilineRegex(stringsToExclude, options, wholeWord); err != nil {
+			return nil, err
+		}
+	}
+	if m.ignoreCase && !m.useRegex {
+		for i := range stringsToExclude {
+			stringsToExclude[i] = strings.ToLower(stringsToExclude[i])
+		}
+	}
+
+	if !m.useRegex {
+		// Strings with broken characters could match any other broken ones.
+		if !strings.ContainsRune(strings.Join(stringsToUse, ""), utf8.RuneError) {
+			m.searchStringsToContain = stringsToUse
+		}
+		m.searchStringsToUse = stringsToIntArrays(stringsToUse)
+		m.searchStringsToExclude = stringsToIntArrays(stringsToExclude)
+		return m, nil
+	}
+
+	if m.searchRegexesToUse, err = m.compileRegexes(stringsToUse); err != nil {
+		return nil, err
+	}
+	if m.searchRegexesToExclude, err = m.compileRegexes(stringsToExclude); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func trimAll(array []string) []string {
+	trimmed := make([]string, 0, len(array))
+	for _, s := range array {
+		s = strings.TrimSpace(s)
+
+		// Remove empty strings.
+		if s != "" {
+			trimmed = append(trimmed, s)
+		}
+	}
+	return trimmed
+}
+
+func stringsToIntArrays(array []string) [][]int {
+	if len(array) == 0 {
+		return nil
+	}
+	intArrays := make([][]int, len(array))
+	for pos, s := range array {
+		intArrays[pos] = stringToIntArray(s)
+	}
+	return intArrays
+}
+
+func (m *matcher) compileRegexes(array []string) ([]*regexp.Regexp, error) {
+	if len(array) == 0 {
+		return nil, nil
+	}
+	regexes := make([]*regexp.Regexp, len(array))
+	for pos, expr := range array {
+		if m.wholeWord {
+			expr = `\b` + expr + `\b`
+		}
+
+		flags := ""
+		if m.ignoreCase {
+			flags = "(?i)"
+		}
+		regex, err := regexp.Compile(flags + expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", expr, err)
+		}
+		regexes[pos] = regex
+	}
+	return regexes, nil
+}
+
+/**************************************************************************/
+
+// Directory and file names glob matching.
+
+func (m *matcher) shouldIncludeFileByNameFilters(baseName, relPath string) bool {
+	return shouldIncludeByNameFilters(baseName, relPath, false, m.fileIncludeFilters, m.fileExcludeFilters)
+}
+
+func (m *matcher) shouldIncludeDirByNameFilters(baseName, relPath string) bool {
+	return shouldIncludeByNameFilters(baseName, relPath, true, m.dirIncludeFilters, m.dirExcludeFilters)
+}
+
+// Archives are searched for the files within them even when they do not
+// match the include filters themselves, as long as they are not excluded.
+func (m *matcher) shouldIncludeArchiveByNameFilters(baseName, relPath string) bool {
+	return m.fileIncludeFilters.matches(baseName, relPath, false) ||
+		!m.fileExcludeFilters.matches(baseName, relPath, false)
+}
+
+func shouldIncludeByNameFilters(baseName, relPath string, isDir bool, includeFilters, excludeFilters *globFilterList) bool {
+	if includeFilters.matches(baseName, relPath, isDir) {
+		return true
+	}
+
+	if excludeFilters.matches(baseName, relPath, false) {
+		return false
+	}
+
+	return includeFilters.isEmpty()
+}
+
+/**************************************************************************/
+
+// Matching utilities.
+
+func isNonWordChar(char rune) bool {
+	return unicode.IsControl(char) ||
+		unicode.IsMark(char) ||
+		unicode.IsPunct(char) ||
+		unicode.IsSpace(char) ||
+		unicode.IsSymbol(char)
+}
+
+/**************************************************************************/
+
+// Matching logic.
+
+// Returns whether the line matches all search strings, and if so, the match
+// spans as byte offsets into line. The spans are only valid until the next
+// call with the same buffers.
+func (m *matcher) matchLine(line string, buffers *matchBuffers) ([]Span, bool) {
+	buffers.spans = buffers.spans[:0]
+	if line == "" {
+		return nil, false
+	}
+
+	if m.useRegex {
+		return m.getMatchSpansByRegexMatch(line, buffers)
+	}
+
+	lineToMatch := line
+	if m.ignoreCase {
+		lineToMatch = strings.ToLower(line)
+	}
+
+	if !m.containsAll(lineToMatch) {
+		return nil, false
+	}
+	buffers.lineAsIntArray = appendStringToIntArray(buffers.lineAsIntArray[:0], lineToMatch)
+	if !m.getMatchSpansByExactMatch(buffers.lineAsIntArray, buffers) {
+		return nil, false
+	}
+
+	// Convert rune indexes into byte offsets of the original line.
+	if len(buffers.lineAsIntArray) != len(line) {
+		buffers.runeOffsets = buffers.runeOffsets[:0]
+		for pos := range line {
+			buffers.runeOffsets = append(buffers.runeOffsets, pos)
+		}
+		buffers.runeOffsets = append(buffers.runeOffsets, len(line))
+		for i, span := range buffers.spans {
+			buffers.spans[i] = Span{
+				Begin: runeIndexToOffset(buffers.runeOffsets, span.Begin),
+				End:   runeIndexToOffset(buffers.runeOffsets, span.End),
+			}
+		}
+	}
+	return buffers.spans, true
+}
+
+func runeIndexToOffset(runeOffsets []int, index int) int {
+	if index >= len(runeOffsets) {
+		return runeOffsets[len(runeOffsets)-1]
+	}
+	return runeOffsets[index]
+}
+
+// Cheaper than matchLine when the match spans are not needed.
+func (m *matcher) isLineMatching(line string, buffers *matchBuffers) bool {
+	if line == "" {
+		return false
+	}
+
+	if m.useRegex {
+		return m.isLineMatchingRegex(line)
+	}
+
+	if m.ignoreCase {
+		line = strings.ToLower(line)
+	}
+
+	if !m.containsAll(line) {
+		return false
+	}
+	buffers.lineAsIntArray = appendStringToIntArray(buffers.lineAsIntArray[:0], line)
+	return m.isLineMatchingIntArray(buffers.lineAsIntArray)
+}
+
+// Most lines don't contain the search strings at all, which is found
+// without turning them into runes.
+func (m *matcher) containsAll(line string) bool {
+	for _, s := range m.searchStringsToContain {
+		if !strings.Contains(line, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *matcher) getMatchSpansByRegexMatch(line string, buffers *matchBuffers) ([]Span, bool) {
+	// Exclude if matches any.
+	for _, regex := range m.searchRegexesToExclude {
+		if regex.MatchString(line) {
+			return nil, false
+		}
+	}
+
+	// Include if matches all.
+	for _, regex := range m.searchRegexesToUse {
+		arrayOfIndexes := regex.FindAllStringIndex(line, -1)
+		if arrayOfIndexes == nil {
+			return nil, false
+		}
+		for _, indexes := range arrayOfIndexes {
+			buffers.spans = append(buffers.spans, Span{Begin: indexes[0], End: indexes[1]})
+		}
+	}
+
+	return buffers.spans, true
+}
+
+func (m *matcher) isLineMatchingRegex(line string) bool {
+	// Exclude if matches any.
+	for _, regex := range m.searchRegexesToExclude {
+		if regex.MatchString(line) {
+			return false
+		}
+	}
+
+	// Include if matches all.
+	for _, regex := range m.searchRegexesToUse {
+		if !regex.MatchString(line) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Case-sensitive matching only.
+// This function takes an int array as argument so that we can check word boundary matches.
+// Using an int array also saves us from breaking down the string into runes multiple times.
+// The spans are appended to buffers.spans as rune indexes.
+func (m *matcher) getMatchSpansByExactMatch(array []int, buffers *matchBuffers) bool {
+	if m.searchStringsToExclude != nil && isExcludedIntArray(array, m.searchStringsToExclude) {
+		return false
+	}
+
+	for pos, searchStringIntArray := range m.searchStringsToUse {
+		stringStartIndex := 0
+		matchCount := 0
+		for {
+			beginIndex := intArrayIndexOf(array, searchStringIntArray, stringStartIndex)
+
+			// Match whole words only.
+			if beginIndex >= 0 && m.wholeWord && !isWholeWordAt(array, beginIndex, len(searchStringIntArray)) {
+				stringStartIndex = beginIndex + len(searchStringIntArray)
+				continue
+			}
+
+			if beginIndex < 0 {
+				break
+			}
+
+			endIndex := beginIndex + len(searchStringIntArray)
+			buffers.spans = append(buffers.spans, Span{Begin: beginIndex, End: endIndex})
+			matchCount++
+
+			if endIndex >= len(array) {
+				break
+			}
+			stringStartIndex = endIndex
+		}
+
+		// Match at least the given number of times.
+		if matchCount < m.searchStringsToUseCount[pos] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isWholeWordAt(array []int, beginIndex, length int) bool {
+	if (beginIndex > 0) &&
+		(!isNonWordChar(rune(array[beginIndex])) &&
+			!isNonWordChar(rune(array[beginIndex-1]))) {
+		return false
+	}
+	endIndex := beginIndex + length
+	if (endIndex < len(array)) &&
+		(!isNonWordChar(rune(array[endIndex])) &&
+			!isNonWordChar(rune(array[endIndex-1]))) {
+		return false
+	}
+	return true
+}
+
+func (m *matcher) isLineMatchingIntArray(array []int) bool {
+	if m.searchStringsToExclude != nil && isExcludedIntArray(array, m.searchStringsToExclude) {
+		return false
+	}
+
+	for pos, searchStringIntArray := range m.searchStringsToUse {
+		stringStartIndex := 0
+		matchCount := 0
+		for matchCount < m.searchStringsToUseCount[pos] {
+			beginIndex := intArrayIndexOf(array, searchStringIntArray, stringStartIndex)
+			if beginIndex < 0 {
+				return false
+			}
+			stringStartIndex = beginIndex + len(searchStringIntArray)
+
+			// Match whole words only.
+			if m.wholeWord && !isWholeWordAt(array, beginIndex, len(searchStringIntArray)) {
+				continue
+			}
+			matchCount++
+		}
+	}
+
+	return true
+}
+
+// Exclude strings are found anywhere, even when matching whole words.
+func isExcludedIntArray(array []int, toFindArray [][]int) bool {
+	for _, searchStringIntArray := range toFindArray {
+		if intArrayIndexOf(array, searchStringIntArray, 0) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func intArrayIndexOf(toSearch, toFind []int, startIndex int) int {
+	endIndex := len(toSearch) - len(toFind) + 1
+Outer:
+	for i := startIndex; i < endIndex; i++ {
+		for j := 0; j < len(toFind); j++ {
+			if toSearch[i+j] != toFind[j] {
+				continue Outer
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+/**************************************************************************/
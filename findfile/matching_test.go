@@ -0,0 +1,164 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Matching lines.
+
+func searchLinesForTest(t *testing.T, text string, setOptions func(options *Options)) []string {
+	t.Helper()
+	options := newTestOptions(fstest.MapFS{"file.txt": {Data: []byte(text)}})
+	setOptions(&options)
+
+	lines := []string{}
+	for _, result := range searchForTest(t, options) {
+		if result.Kind == ResultLine {
+			lines = append(lines, result.Text)
+		}
+	}
+	return lines
+}
+
+func TestMatchLines(t *testing.T) {
+	text := "value option\nvalue opt\nvalue\nValue\nvalues\nfoo foo\nfoo\nfoo bar foo\n"
+	tests := []struct {
+		name          string
+		searchStrings []string
+		setOptions    func(options *Options)
+		want          []string
+	}{
+		{
+			"plain", []string{"value"}, func(*Options) {},
+			[]string{"value option", "value opt", "value", "values"},
+		},
+		{
+			"ignore case", []string{"VALUE"}, func(options *Options) { options.IgnoreCase = true },
+			[]string{"value option", "value opt", "value", "Value", "values"},
+		},
+		{
+			"whole word", []string{"value"}, func(options *Options) { options.WholeWord = true },
+			[]string{"value option", "value opt", "value"},
+		},
+		{
+			"all strings", []string{"foo", "bar"}, func(*Options) {},
+			[]string{"foo bar foo"},
+		},
+		{
+			"repeated strings", []string{"foo", "foo"}, func(*Options) {},
+			[]string{"foo foo", "foo bar foo"},
+		},
+		{
+			"exclude strings", []string{"value"}, func(options *Options) {
+				options.ExcludeStrings = []string{"opt"}
+			},
+			[]string{"value", "values"},
+		},
+		{
+			// Exclude strings are found anywhere, even within words.
+			"whole word exclude strings", []string{"value"}, func(options *Options) {
+				options.WholeWord = true
+				options.ExcludeStrings = []string{"opt"}
+			},
+			[]string{"value"},
+		},
+		{
+			"regex", []string{`^val\w+$`}, func(options *Options) { options.Regex = true },
+			[]string{"value", "values"},
+		},
+		{
+			"invert", []string{"o"}, func(options *Options) { options.InvertMatch = true },
+			[]string{"value", "Value", "values"},
+		},
+	}
+	for _, test := range tests {
+		got := searchLinesForTest(t, text, func(options *Options) {
+			options.SearchStrings = test.searchStrings
+			test.setOptions(options)
+		})
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMatchSpans(t *testing.T) {
+	// Ⱥ takes 2 bytes and its lowercase ⱥ takes 3.
+	tests := []struct {
+		name          string
+		searchStrings []string
+		regex         bool
+		line          string
+		want          []Span
+	}{
+		{"ignore case", []string{"ABC"}, false, "ȺȺȺ abc ABC", []Span{{7, 10}, {11, 14}}},
+		{"ignore case regex", []string{"abc"}, true, "ȺȺȺ abc ABC", []Span{{7, 10}, {11, 14}}},
+		{"lowercase string", []string{"ⱥb"}, false, "xȺB ȺȺ", []Span{{1, 4}}},
+		{"lowercase regex", []string{"ⱥ+"}, true, "xȺȺ ⱥ", []Span{{1, 5}, {6, 9}}},
+		{"regex classes keep their case", []string{`\S+c`}, true, "x ȺBC", []Span{{2, 6}}},
+	}
+	for _, test := range tests {
+		options := &Options{SearchStrings: test.searchStrings, IgnoreCase: true, Regex: test.regex}
+		m, err := newMatcher(options)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		spans, ok := m.matchLine(test.line, &matchBuffers{})
+		if !ok || !reflect.DeepEqual(spans, test.want) {
+			t.Errorf("%v: got %v %v, want %v", test.name, ok, spans, test.want)
+		}
+	}
+}
+
+func TestCountRepeatedStrings(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte("foo foo\nfoo\nfoo bar foo\nfoobar foo\n")},
+		"b.txt": {Data: []byte("foo\n")},
+	}
+	options := newTestOptions(fsys)
+	options.SearchStrings = []string{"foo", "foo"}
+	options.WholeWord = true
+	options.CountOnly = true
+
+	var got []string
+	var numMatches []int
+	for _, result := range searchForTest(t, options) {
+		if result.Kind == ResultFile {
+			got = append(got, result.Path)
+			numMatches = append(numMatches, result.NumMatches)
+		}
+	}
+	if !reflect.DeepEqual(got, []string{"a.txt"}) || !reflect.DeepEqual(numMatches, []int{2}) {
+		t.Errorf("got %q with %v matches, want a.txt with 2", got, numMatches)
+	}
+}
+
+/**************************************************************************/
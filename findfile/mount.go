@@ -0,0 +1,129 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/**************************************************************************/
+
+// Mount points.
+
+const mountInfoPath = "/proc/self/mountinfo"
+
+// Reads the mount points of the given file system types from the mount
+// table, keyed by their absolute paths.
+func loadMountPoints(fsTypes []string) (map[string]string, error) {
+	data, err := os.ReadFile(mountInfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read mount table: %v", err)
+	}
+	return parseMountPoints(data, fsTypes), nil
+}
+
+func parseMountPoints(data []byte, fsTypes []string) map[string]string {
+	wanted := make(map[string]bool)
+	for _, fsType := range fsTypes {
+		wanted[fsType] = true
+	}
+
+	mountPoints := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// The fields after the mount point end with a "-" separator,
+		// followed by the file system type.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		for i := 5; i+1 < len(fields); i++ {
+			if fields[i] == "-" {
+				if wanted[fields[i+1]] {
+					mountPoints[unescapeMountPath(fields[4])] = fields[i+1]
+				}
+				break
+			}
+		}
+	}
+	return mountPoints
+}
+
+// The mount table escapes spaces and other special characters in octal,
+// e.g. "\040" for a space.
+func unescapeMountPath(str string) string {
+	if !strings.Contains(str, `\`) {
+		return str
+	}
+	var buffer strings.Builder
+	for i := 0; i < len(str); i++ {
+		if (str[i] == '\\') && (i+4 <= len(str)) {
+			if value, err := strconv.ParseUint(str[i+1:i+4], 8, 8); err == nil {
+				buffer.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		buffer.WriteByte(str[i])
+	}
+	return buffer.String()
+}
+
+// Tells whether the dir should not be walked because it is on another file
+// system than its parent dir, or because its file system type is excluded,
+// reporting it if so.
+func (w *searchWorker) isPrunedMount(dirPath string, fileInfo fs.FileInfo, parent *walkNode) bool {
+	reason := ""
+	if w.options.OneFileSystem {
+		id, ok := getFileID(fileInfo)
+		parentID, parentOK := getFileID(parent.fileInfo)
+		if ok && parentOK && (id.device != parentID.device) {
+			reason = "other file system"
+		}
+	}
+
+	if (reason == "") && (len(w.excludedMountPoints) > 0) {
+		if absPath, err := filepath.Abs(dirPath); err == nil {
+			if fsType, ok := w.excludedMountPoints[absPath]; ok {
+				reason = "file system type " + fsType
+			}
+		}
+	}
+
+	if reason == "" {
+		return false
+	}
+	w.report(&Result{Kind: ResultPrunedMount, Path: dirPath, IsDir: true, Text: reason})
+	return true
+}
+
+/**************************************************************************/
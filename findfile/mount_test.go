@@ -0,0 +1,114 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+/**************************************************************************/
+
+// Mount points.
+
+func TestParseMountPoints(t *testing.T) {
+	data := []byte(`22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+23 22 0:21 / /proc rw,nosuid - proc proc rw
+24 22 0:22 / /mnt/my\040share rw shared:2 master:1 - nfs server:/export rw
+25 22 0:23 / /mnt/other rw - nfs4 server:/other rw
+26 22 0:24 / /broken rw
+`)
+	got := parseMountPoints(data, []string{"nfs", "proc"})
+	want := map[string]string{
+		"/proc":         "proc",
+		"/mnt/my share": "nfs",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUnescapeMountPath(t *testing.T) {
+	tests := map[string]string{
+		"/plain":            "/plain",
+		`/a\040b`:           "/a b",
+		`/tab\011and\134bs`: "/tab\tand\\bs",
+		`/not\999octal`:     `/not\999octal`,
+		`/short\04`:         `/short\04`,
+	}
+	for str, want := range tests {
+		if got := unescapeMountPath(str); got != want {
+			t.Errorf("unescapeMountPath(%q) = %q, want %q", str, got, want)
+		}
+	}
+}
+
+// Dirs on excluded file system types are reported and not walked.
+func TestExcludedMountPoints(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"local/a.txt", "mounted/b.txt"} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options := DefaultOptions()
+	options.Roots = []string{dir}
+	options.ListAll = true
+	searcher, err := NewSearcher(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	searcher.excludedMountPoints = map[string]string{filepath.Join(dir, "mounted"): "nfs"}
+
+	var entries, pruned []string
+	err = searcher.Search(context.Background(), func(result *Result) error {
+		switch result.Kind {
+		case ResultEntry:
+			entries = append(entries, filepath.Base(result.Path))
+		case ResultPrunedMount:
+			pruned = append(pruned, filepath.Base(result.Path)+": "+result.Text)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"local", "a.txt"}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("got entries %q, want %q", entries, want)
+	}
+	if want := []string{"mounted: file system type nfs"}; !reflect.DeepEqual(pruned, want) {
+		t.Errorf("got pruned mounts %q, want %q", pruned, want)
+	}
+}
+
+/**************************************************************************/
@@ -0,0 +1,405 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+/**************************************************************************/
+
+// Multiline matching.
+
+// Files up to this size are searched as a whole, and bigger files through
+// a window of this size sliding along the file, so that memory use stays
+// bounded however big the file.
+const multilineWindowSize = 16 << 20
+
+// Longest match found across the edge of two windows. Longer matches may
+// be missed in files bigger than a window.
+const maxMultilineMatchLength = multilineWindowSize / 4
+
+// Size of the reads filling the window.
+const multilineReadSize = 64 * 1024
+
+// Returns a regex finding any of the expressions, where '^' and '$' match
+// at line boundaries. Case is ignored with the (?i) flag rather than by
+// lowering the text as for lines, which could move the byte offsets.
+// Exclude strings that are not regexes are found anywhere, as for lines.
+func compileMultilineRegex(exprs []string, options *Options, wholeWord bool) (*regexp.Regexp, error) {
+	alternatives := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		if expr == "" {
+			continue
+		}
+		if !options.Regex {
+			expr = regexp.QuoteMeta(expr)
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", expr, err)
+		}
+		if wholeWord {
+			expr = `\b(?:` + expr + `)\b`
+		}
+		alternatives = append(alternatives, "(?:"+expr+")")
+	}
+	if len(alternatives) == 0 {
+		return nil, nil
+	}
+
+	flags := "(?m)"
+	if options.IgnoreCase {
+		flags = "(?mi)"
+	}
+	return regexp.Compile(flags + strings.Join(alternatives, "|"))
+}
+
+// A multilineReader holds a window of a file, which always starts at a
+// line boundary unless a line is longer than the window.
+type multilineReader struct {
+	reader       io.Reader
+	buffer       []byte
+	isFileEnded  bool
+	numBytesRead int64
+
+	// The first error other than io.EOF, which ends the file early.
+	err error
+
+	// Byte offset within the file, line number and 1-indexed column of the
+	// start of the buffer.
+	offset     int64
+	lineNumber int
+	column     int
+
+	// Position reached by the last call to position, for counting lines
+	// from there on.
+	cursor           int
+	cursorLineNumber int
+	cursorLineStart  int
+}
+
+func newMultilineReader(reader io.Reader) *multilineReader {
+	mr := &multilineReader{reader: reader, lineNumber: 1, column: 1}
+	mr.resetCursor()
+	return mr
+}
+
+// Reads until the window is full or the file ends, returning false when
+// there is nothing left in the window.
+func (mr *multilineReader) fill() bool {
+	for !mr.isFileEnded && (mr.err == nil) && (len(mr.buffer) < multilineWindowSize) {
+		size := multilineWindowSize - len(mr.buffer)
+		if size > multilineReadSize {
+			size = multilineReadSize
+		}
+		if cap(mr.buffer)-len(mr.buffer) < size {
+			newCap := 2*cap(mr.buffer) + size
+			if newCap > multilineWindowSize {
+				newCap = multilineWindowSize
+			}
+			mr.buffer = append(make([]byte, 0, newCap), mr.buffer...)
+		}
+		n, err := mr.reader.Read(mr.buffer[len(mr.buffer) : len(mr.buffer)+size])
+		mr.buffer = mr.buffer[:len(mr.buffer)+n]
+		mr.numBytesRead += int64(n)
+
+		if err == io.EOF {
+			mr.isFileEnded = true
+		} else if err != nil {
+			mr.err = err
+		}
+	}
+	return len(mr.buffer) > 0
+}
+
+// Whether the window holds the rest of the file.
+func (mr *multilineReader) isAtEnd() bool {
+	return mr.isFileEnded || (mr.err != nil)
+}
+
+// Drops the start of the window up to end, rounded down to the start of a
+// line so that lines are kept whole, and keeping numLines lines more.
+// Returns how many bytes were dropped.
+func (mr *multilineReader) discard(end, numLines int) int {
+	cut := lineStartBefore(mr.buffer, end, numLines)
+	if cut == 0 {
+		// The line is longer than the window, so it is cut in two.
+		cut = end
+		for (cut > 0) && (cut < len(mr.buffer)) && !utf8.RuneStart(mr.buffer[cut]) {
+			cut--
+		}
+	}
+
+	if cut < mr.cursor {
+		mr.resetCursor()
+	}
+	mr.lineNumber, mr.column = mr.position(cut)
+	mr.offset += int64(cut)
+	mr.buffer = append(mr.buffer[:0], mr.buffer[cut:]...)
+	mr.resetCursor()
+	return cut
+}
+
+func (mr *multilineReader) resetCursor() {
+	mr.cursor, mr.cursorLineNumber, mr.cursorLineStart = 0, mr.lineNumber, 0
+	if mr.column > 1 {
+		mr.cursorLineStart = -1
+	}
+}
+
+// Returns the line number and 1-indexed column of the given position in
+// the window. Positions must be asked for in increasing order until the
+// next discard.
+func (mr *multilineReader) position(pos int) (int, int) {
+	for {
+		i := bytes.IndexByte(mr.buffer[mr.cursor:pos], '\n')
+		if i < 0 {
+			break
+		}
+		mr.cursor += i + 1
+		mr.cursorLineNumber++
+		mr.cursorLineStart = mr.cursor
+	}
+	mr.cursor = pos
+
+	if mr.cursorLineStart < 0 {
+		return mr.cursorLineNumber, mr.column + utf8.RuneCount(mr.buffer[:pos])
+	}
+	return mr.cursorLineNumber, 1 + utf8.RuneCount(mr.buffer[mr.cursorLineStart:pos])
+}
+
+// Returns the start of the line holding pos, moved back by numLines more
+// lines, or 0 when the window starts first.
+func lineStartBefore(buffer []byte, pos, numLines int) int {
+	for {
+		i := bytes.LastIndexByte(buffer[:pos], '\n')
+		if i < 0 {
+			return 0
+		}
+		if numLines == 0 {
+			return i + 1
+		}
+		numLines--
+		pos = i
+	}
+}
+
+// Returns the end of the line holding pos, before its line end, or the
+// end of the window.
+func lineEndAfter(buffer []byte, pos int) int {
+	i := bytes.IndexByte(buffer[pos:], '\n')
+	if i < 0 {
+		return len(buffer)
+	}
+	return pos + i
+}
+
+// Returns the start of the last character before pos.
+func lastRuneStart(buffer []byte, pos int) int {
+	_, size := utf8.DecodeLastRune(buffer[:pos])
+	return pos - size
+}
+
+// Searches the whole file at once rather than line by line, reporting
+// each match, which may span several lines.
+func (w *searchWorker) searchMultilineContents(path, encoding string, file io.Reader, isBinary bool) error {
+	reader := newMultilineReader(file)
+	defer func() {
+		atomic.AddInt64(&w.numBytesRead, reader.numBytesRead)
+
+		// Read errors end the file early.
+		if reader.err != nil {
+			w.reportError(path, reader.err)
+		}
+	}()
+
+	numMatches := 0
+	searchFrom := 0
+	for !w.isCancelled() && reader.fill() {
+		// Matches starting near the end of the window may be cut short, so
+		// they are left for the next window.
+		limit := len(reader.buffer)
+		if !reader.isAtEnd() {
+			limit -= maxMultilineMatchLength
+		}
+
+		// As with lines, every search string must be found.
+		var allIndexes [][]int
+		if w.matcher.isMatchingAllMultiline(reader.buffer) {
+			allIndexes = w.matcher.multilineRegex.FindAllIndex(reader.buffer, -1)
+		}
+		for _, indexes := range allIndexes {
+			begin, end := indexes[0], indexes[1]
+			if (begin < searchFrom) || (begin == end) {
+				continue
+			}
+			if begin >= limit {
+				break
+			}
+			searchFrom = end
+
+			result := w.newMultilineResult(path, encoding, reader, begin, end)
+			if result == nil {
+				continue
+			}
+			numMatches++
+
+			if isBinary && (w.options.BinaryFiles == BinaryFilesReport) {
+				return w.report(&Result{Kind: ResultBinaryFile, Path: path, Offset: reader.offset + int64(begin)})
+			}
+			if w.options.CountOnly {
+				continue
+			}
+			if err := w.report(result); err != nil {
+				return err
+			}
+			if w.isCancelled() {
+				return nil
+			}
+		}
+
+		if reader.isAtEnd() {
+			break
+		}
+		if searchFrom < limit {
+			searchFrom = limit
+		}
+		searchFrom -= reader.discard(searchFrom, w.options.ContextLines)
+	}
+
+	if w.options.CountOnly && (numMatches > 0) && !w.isCancelled() {
+		return w.report(&Result{Kind: ResultFile, Path: path, NumMatches: numMatches, Encoding: encoding})
+	}
+	return nil
+}
+
+// Tells whether the window holds a match of every search string, when
+// there are several.
+func (m *matcher) isMatchingAllMultiline(buffer []byte) bool {
+	for _, regex := range m.multilineRegexesToUse {
+		if !regex.Match(buffer) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the result of a match, with the whole lines it covers as its
+// text, or nil when these lines hold an excluded string.
+func (w *searchWorker) newMultilineResult(path, encoding string, reader *multilineReader, begin, end int) *Result {
+	buffer := reader.buffer
+	last := lastRuneStart(buffer, end)
+	textBegin := lineStartBefore(buffer, begin, 0)
+	textEnd := lineEndAfter(buffer, last)
+	contextBegin, contextEnd := textBegin, textEnd
+
+	// Long lines are cut some way off the match, as with Options.LongLines.
+	maxLineLength := w.options.MaxLineLength
+	if begin-textBegin > maxLineLength {
+		textBegin = begin - maxLineLength
+		for !utf8.RuneStart(buffer[textBegin]) {
+			textBegin++
+		}
+	}
+	if textEnd-end > maxLineLength {
+		textEnd = end + maxLineLength
+		for !utf8.RuneStart(buffer[textEnd]) {
+			textEnd--
+		}
+	}
+	text := string(bytes.TrimSuffix(buffer[textBegin:textEnd], []byte("\r")))
+
+	if (w.matcher.multilineExcludeRegex != nil) && w.matcher.multilineExcludeRegex.MatchString(text) {
+		return nil
+	}
+
+	lineNumber, column := reader.position(begin)
+	endLineNumber, endColumn := reader.position(last)
+	spanEnd := end - textBegin
+	if spanEnd > len(text) {
+		spanEnd = len(text)
+	}
+	result := &Result{
+		Kind:          ResultLine,
+		Path:          path,
+		LineNumber:    lineNumber,
+		Column:        column,
+		EndLineNumber: endLineNumber,
+		EndColumn:     endColumn,
+		Text:          text,
+		Encoding:      encoding,
+		Spans:         []Span{{Begin: begin - textBegin, End: spanEnd}},
+	}
+
+	numContextLines := w.options.ContextLines
+	if numContextLines > 0 {
+		// The window keeps enough lines before the match, but the lines
+		// after it may be cut at the end of the window.
+		result.Before = contextLinesBefore(buffer[:contextBegin], lineNumber, numContextLines)
+		if contextEnd < len(buffer) {
+			result.After = contextLinesAfter(buffer[contextEnd+1:], endLineNumber, numContextLines)
+		}
+	}
+	return result
+}
+
+// Returns up to numLines lines at the end of buffer, which ends with a line
+// end, numbered up to the given line number.
+func contextLinesBefore(buffer []byte, lineNumber, numLines int) []Line {
+	if len(buffer) == 0 {
+		return nil
+	}
+	begin := lineStartBefore(buffer, len(buffer)-1, numLines-1)
+	lines := strings.Split(string(buffer[begin:len(buffer)-1]), "\n")
+	contextLines := make([]Line, len(lines))
+	for pos, line := range lines {
+		contextLines[pos] = Line{Number: lineNumber - len(lines) + pos, Text: strings.TrimSuffix(line, "\r")}
+	}
+	return contextLines
+}
+
+// Returns up to numLines lines at the start of buffer, numbered from after
+// the given line number.
+func contextLinesAfter(buffer []byte, lineNumber, numLines int) []Line {
+	var contextLines []Line
+	for (len(buffer) > 0) && (len(contextLines) < numLines) {
+		end := lineEndAfter(buffer, 0)
+		line := string(bytes.TrimSuffix(buffer[:end], []byte("\r")))
+		contextLines = append(contextLines, Line{Number: lineNumber + 1 + len(contextLines), Text: line})
+		if end == len(buffer) {
+			break
+		}
+		buffer = buffer[end+1:]
+	}
+	return contextLines
+}
+
+/**************************************************************************/
@@ -0,0 +1,126 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Matches spanning lines.
+
+func searchMultilineForTest(t *testing.T, text string, expr string) []*Result {
+	t.Helper()
+	options := newTestOptions(fstest.MapFS{"file.txt": {Data: []byte(text)}})
+	options.SearchStrings = []string{expr}
+	options.Regex = true
+	options.Multiline = true
+
+	var results []*Result
+	for _, result := range searchForTest(t, options) {
+		if result.Kind == ResultLine {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func TestMultiline(t *testing.T) {
+	results := searchMultilineForTest(t, "one\ntwo\nthree\nfour\n", `wo\nth`)
+	if len(results) != 1 {
+		t.Fatalf("got %v results, want 1", len(results))
+	}
+	result := results[0]
+	got := []int{result.LineNumber, result.Column, result.EndLineNumber, result.EndColumn}
+	if want := []int{2, 2, 3, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got position %v, want %v", got, want)
+	}
+	if result.Text != "two\nthree" {
+		t.Errorf("got text %q", result.Text)
+	}
+}
+
+func TestMultilineAllStrings(t *testing.T) {
+	fsys := fstest.MapFS{
+		"both.txt":  {Data: []byte("alpha\nbeta\ngamma alpha\n")},
+		"alpha.txt": {Data: []byte("alpha\n")},
+		"beta.txt":  {Data: []byte("beta\n")},
+	}
+	options := newTestOptions(fsys)
+	options.SearchStrings = []string{"alpha", `b\w+\ng`}
+	options.Regex = true
+	options.Multiline = true
+
+	var got []string
+	for _, result := range searchForTest(t, options) {
+		if result.Kind == ResultLine {
+			got = append(got, fmt.Sprintf("%v:%v %q", result.Path, result.LineNumber, result.Text))
+		}
+	}
+	want := []string{`both.txt:1 "alpha"`, `both.txt:2 "beta\ngamma alpha"`, `both.txt:3 "gamma alpha"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMultilineAcrossWindows(t *testing.T) {
+	// Each match begins a few bytes before the end of a read or a window.
+	positions := []int{
+		multilineReadSize - 3,
+		multilineWindowSize - 3,
+		multilineWindowSize + multilineReadSize - 3,
+		multilineWindowSize + 3<<20,
+	}
+	var text strings.Builder
+	var wantLines []int
+	numLines := 0
+	for _, position := range positions {
+		filler := strings.Repeat("-", 63) + "\n"
+		for text.Len()+2*len(filler) <= position {
+			text.WriteString(filler)
+			numLines++
+		}
+		text.WriteString(strings.Repeat("x", position-text.Len()-1) + "\n")
+		numLines++
+		wantLines = append(wantLines, numLines+1)
+		text.WriteString("begin\nend\n")
+		numLines += 2
+	}
+
+	var gotLines []int
+	for _, result := range searchMultilineForTest(t, text.String(), `begin\nend`) {
+		gotLines = append(gotLines, result.LineNumber)
+	}
+	if !reflect.DeepEqual(gotLines, wantLines) {
+		t.Errorf("got lines %v, want %v", gotLines, wantLines)
+	}
+}
+
+/**************************************************************************/
@@ -0,0 +1,378 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package findfile is the search engine behind the ff command. It walks a
+// dir tree, matches dir and file names and file contents against a list of
+// search strings, and streams each result to a callback.
+package findfile
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+/**************************************************************************/
+
+// Search options.
+
+// Options controls where a Searcher searches and what it looks for.
+// The zero value searches nothing; use DefaultOptions as a starting point.
+type Options struct {
+	// File system to search, which defaults to the local file system.
+	// Symbolic links are detected with Lstat if it implements LstatFS, or
+	// else from the types of the dir entries it lists.
+	FS fs.FS
+
+	// Starting dir to search, as a path within FS. It is only searched when
+	// Roots and Paths are both nil.
+	Dir string
+
+	// Starting dirs to search, as paths within FS. Files may also be given,
+	// and are searched on their own.
+	Roots []string
+
+	// Dirs and files to search on their own, without walking into dirs,
+	// e.g. the files listed by "git ls-files". Ignore files do not apply
+	// to them, but the other filters and the depth limit do, with glob
+	// patterns matching their paths relative to Dir or PathRoots.
+	Paths []string
+
+	// Dirs that the Paths were listed from, such as git work trees. Each
+	// path is filtered relative to the deepest of these dirs and Dir that
+	// holds it, as if it was found by walking that dir.
+	PathRoots []string
+
+	// Search up to the given dir depth, 0 to search the starting dir only,
+	// and -1 for no limit.
+	MaxLevels int
+
+	// Follow symbolic links to dirs and files instead of skipping them.
+	// A link back to one of its own parent dirs is not followed, and a file
+	// or dir reached through several links is only searched once, under
+	// the first path found. Both checks need the local file system.
+	FollowLinks bool
+
+	// Search each dir and file only once however many paths lead to it,
+	// such as hard links and bind mounts, telling them apart by device and
+	// inode number. The first path in depth-first order is kept, except
+	// that with several Jobs a dir may be kept under a later path. This
+	// needs the local file system and CanDedupeFiles, and is always done
+	// when FollowLinks is set.
+	DedupeFiles bool
+
+	// Report each path skipped by DedupeFiles or FollowLinks as a
+	// ResultDuplicate.
+	ReportDuplicates bool
+
+	// Don't walk into dirs on other file systems than their starting dir,
+	// like find -xdev. Each such mount point is reported as a
+	// ResultPrunedMount.
+	OneFileSystem bool
+
+	// Don't walk into the mount points of these file system types, such as
+	// "proc" or "sysfs", as listed in /proc/self/mountinfo. Each such mount
+	// point is reported as a ResultPrunedMount.
+	ExcludeFSTypes []string
+
+	// Report each symbolic link whose target does not exist as a
+	// ResultBrokenLink, whether or not links are followed.
+	ReportBrokenLinks bool
+
+	// List all the dir and file names without searching.
+	ListAll bool
+
+	// Search dir and file names only, ignoring file contents.
+	SearchNamesOnly bool
+
+	// Search file contents only, ignoring dir and file names.
+	SearchContentsOnly bool
+
+	// Glob patterns for the files and dirs to include or exclude. Patterns
+	// without a slash match the base name, as with filepath.Match, and the
+	// others match the path relative to the starting dir, where "**" matches
+	// any number of dirs. Both kinds accept "{a,b}" alternatives, and a
+	// leading '!' negates a pattern, with the last matching pattern deciding.
+	IncludeFiles []string
+	IncludeDirs  []string
+	ExcludeFiles []string
+	ExcludeDirs  []string
+
+	// Only search the files of these named kinds, and don't search the files
+	// of the excluded kinds, on top of the other filters. Dirs are never
+	// filtered by kind. See DefaultFileKinds.
+	IncludeKinds []FileKind
+	ExcludeKinds []FileKind
+
+	// Only list and search the dirs and files of these types, though dirs
+	// are still walked. Pipes, sockets and devices are never opened, and
+	// are skipped unless their type is given. Symbolic links are listed
+	// as they are when TypeLink is given and FollowLinks is not set.
+	Types []FileType
+
+	// Search the entries of zip, jar, war, tar, tar.gz and tgz archives as
+	// if they were files, with paths like "outer.zip!/inner/path.txt".
+	// The filters apply to these paths, but ignore files do not.
+	SearchArchives bool
+
+	// Number of levels of archives within archives to search, where 1 only
+	// searches the archives in the tree. Defaults to DefaultMaxArchiveDepth
+	// when 0.
+	MaxArchiveDepth int
+
+	// Search the decompressed contents of gzip, bzip2 and zlib files, and of
+	// the other formats added with RegisterCodec, detected by their first
+	// bytes. Line numbers count the decompressed lines.
+	Decompress bool
+
+	// Only search files of at least MinSize bytes, and of at most MaxSize
+	// bytes unless MaxSize is 0. Dirs are never filtered by size.
+	MinSize int64
+	MaxSize int64
+
+	// Only search files last modified after NewerThan and before OlderThan,
+	// unless they are the zero time. Dirs are never filtered by time.
+	NewerThan time.Time
+	OlderThan time.Time
+
+	// Skip the dirs and files matched by the ignore files in each dir, using
+	// the rules of .gitignore files. The ignore files in the dirs above the
+	// starting dir are also used, up to the top of its git repository.
+	UseIgnoreFiles bool
+
+	// Names of the ignore files to read from each dir.
+	// Defaults to DefaultIgnoreFileNames when empty.
+	IgnoreFileNames []string
+
+	// Path to an ignore file on the local file system that applies to the
+	// whole search, like git's core.excludesFile. Its rules are anchored at
+	// the top of the git repository of the starting dir, if any.
+	GlobalIgnoreFile string
+
+	// Report each dir and file skipped by an ignore file as a ResultIgnored.
+	ReportIgnored bool
+
+	// Files that must never be searched, e.g. the file we write output to.
+	SkipFiles []os.FileInfo
+
+	// Strings that must all appear in a line (or name) for it to match.
+	// Repeating a string requires it to appear at least that many times.
+	SearchStrings []string
+
+	// Lines containing any of these strings will not match.
+	ExcludeStrings []string
+
+	IgnoreCase  bool
+	WholeWord   bool
+	Regex       bool
+	InvertMatch bool
+
+	// Treat the search strings as hex byte patterns like "DE AD ?? EF",
+	// where "??" matches any byte, and search the raw bytes of every file
+	// for any of them, reporting each match as a ResultBytes. Files are
+	// neither decoded nor told apart from text, and names never match.
+	HexPatterns bool
+
+	// Search the strings found in the raw bytes of every file instead of
+	// their lines, like the strings tool: runs of at least MinStringLength
+	// printable UTF-8 characters, or UTF-16LE characters below U+0100.
+	// Each string is numbered as a line, and its byte offset is reported
+	// as the Offset of its ResultLine.
+	Strings bool
+
+	// Defaults to DefaultMinStringLength when 0.
+	MinStringLength int
+
+	// Search each file as a whole rather than line by line, reporting each
+	// match of the search strings, which may span several lines, in the
+	// files that contain all of them. In regexes, '^' and '$' match at line
+	// boundaries, and '.' does not match a line end unless with the (?s)
+	// flag. Files bigger than 16MB are searched through a sliding window,
+	// missing the matches longer than 4MB across its edge, and each window
+	// must contain all the search strings. Exclude strings drop the matches whose
+	// lines contain them. Cannot be used with InvertMatch.
+	Multiline bool
+
+	// What to do with the files that look binary, judging by their first
+	// bytes. By default they are skipped.
+	BinaryFiles BinaryFilePolicy
+
+	// Encoding of the files to search, such as EncodingUTF16LE, which are
+	// decoded to UTF-8 before matching. When empty, the encoding is told by
+	// the byte order mark of each file, or guessed from its first bytes,
+	// recognizing UTF-16, UTF-32 and latin-1 or windows-1252 text.
+	Encoding string
+
+	// Lines longer than MaxLineLength bytes are handled as told by
+	// LongLines, so that memory use stays bounded however long the lines.
+	// Defaults to DefaultMaxLineLength when 0.
+	MaxLineLength int
+	LongLines     LongLinePolicy
+
+	// Unit of the file contents matched against the search strings, which
+	// is each line by default. Records of several lines are reported as a
+	// single ResultLine whose text holds their lines, with the line number
+	// they start on, and with columns counted from their start as if they
+	// were one line. Context lines are then context records.
+	Records RecordSeparator
+
+	// Regex matching the first line of each record, with RecordsRegex.
+	RecordRegex string
+
+	// Number of lines to report before and after each matching line.
+	ContextLines int
+
+	// Report each matching file once with its number of matching lines,
+	// instead of reporting every matching line.
+	CountOnly bool
+
+	// Number of goroutines reading dirs and scanning files at the same time.
+	// Defaults to the number of CPUs when 0.
+	Jobs int
+
+	// Pass on results as soon as they are found, in no particular order.
+	// By default results are passed on in depth-first order.
+	Unordered bool
+
+	// Called for errors that do not stop the search, e.g. unreadable dirs
+	// and files, which are then left out of the search. It is never called
+	// at the same time as a ResultFunc.
+	OnError func(path string, err error)
+}
+
+// DefaultOptions returns the options used by ff when no flags are given.
+func DefaultOptions() Options {
+	return Options{
+		Dir:            ".",
+		MaxLevels:      -1,
+		UseIgnoreFiles: true,
+	}
+}
+
+/**************************************************************************/
+
+// Search results.
+
+// ResultKind tells what a Result refers to.
+type ResultKind int
+
+// Kinds of results.
+const (
+	// A dir or file listed without searching (Options.ListAll).
+	ResultEntry ResultKind = iota
+
+	// A dir or file whose base name matches.
+	ResultName
+
+	// A matching line within a file.
+	ResultLine
+
+	// A file with matching lines, reported once (Options.CountOnly).
+	ResultFile
+
+	// A dir or file skipped because of an ignore file (Options.ReportIgnored).
+	// Text tells the ignore file, line number and pattern responsible.
+	ResultIgnored
+
+	// A symbolic link whose target does not exist (Options.ReportBrokenLinks).
+	// Text is the target of the link.
+	ResultBrokenLink
+
+	// A dir that was not walked into because it is a mount point
+	// (Options.OneFileSystem or Options.ExcludeFSTypes). Text tells why.
+	ResultPrunedMount
+
+	// A dir or file skipped because it was already searched under another
+	// path (Options.ReportDuplicates). Text is the path it was searched under.
+	ResultDuplicate
+
+	// A binary file with matching lines, reported once without its contents
+	// (Options.BinaryFiles). Offset is the byte offset of the first match.
+	ResultBinaryFile
+
+	// A match of a byte pattern (Options.HexPatterns). Offset is its byte
+	// offset, and Text holds the raw bytes around it, from the HexRowSize
+	// row before the match to the row after it, with Spans the match.
+	ResultBytes
+)
+
+// Span is a match within Result.Text, as byte offsets.
+type Span struct {
+	Begin int
+	End   int
+}
+
+// Line is a context line reported along with a matching line.
+type Line struct {
+	Number int
+	Text   string
+}
+
+// Result is one search result.
+type Result struct {
+	Kind ResultKind
+
+	// Path of the dir or file within Options.FS, joined onto its starting dir.
+	Path  string
+	IsDir bool
+
+	// Size, modification time and so on of a ResultEntry.
+	Info fs.FileInfo
+
+	// Line and column numbers of a ResultLine, 1-indexed, where the
+	// leftmost match begins and where its last character is.
+	LineNumber    int
+	Column        int
+	EndLineNumber int
+	EndColumn     int
+
+	// The matching line for a ResultLine, or the lines covered by the match
+	// in Options.Multiline mode, or the base name for a ResultName.
+	Text string
+
+	// Encoding that the file of a ResultLine or ResultFile was decoded from.
+	Encoding string
+
+	// Matches within Text. Empty when Options.InvertMatch is set.
+	Spans []Span
+
+	// Context lines around a ResultLine, up to Options.ContextLines each.
+	Before []Line
+	After  []Line
+
+	// Number of matching lines for a ResultFile.
+	NumMatches int
+
+	// Byte offset of the first match in a ResultBinaryFile, of the match in
+	// a ResultBytes, or of the string of a ResultLine with Options.Strings,
+	// within the decompressed contents when decompressing.
+	Offset int64
+}
+
+// ResultFunc is called for each result, one at a time.
+// Returning StopSearch ends the search without error.
+// Returning any other error ends the search with that error.
+type ResultFunc func(result *Result) error
+
+/**************************************************************************/
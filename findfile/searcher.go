@@ -0,0 +1,1098 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+/**************************************************************************/
+
+// Searcher.
+
+// StopSearch can be returned by a ResultFunc to end the search early
+// without error, e.g. after enough results have been seen.
+var StopSearch = errors.New("stop search")
+
+// Searcher searches a dir tree using a fixed set of options.
+// Searchers do not share any state, so any number of them can run at once.
+type Searcher struct {
+	options  Options
+	matcher  *matcher
+	fsys     fs.FS
+	joinPath func(elem ...string) string
+
+	ignoreFileNames []string
+
+	// Filters for Options.IncludeKinds and Options.ExcludeKinds, nil if empty.
+	includeKinds *fileKindFilter
+	excludeKinds *fileKindFilter
+
+	// Regex for Options.RecordRegex, nil unless used.
+	recordRegex *regexp.Regexp
+
+	// Absolute paths of the mount points of excluded file system types.
+	excludedMountPoints map[string]string
+
+	numDirsRead  int64
+	numFilesRead int64
+	numBytesRead int64
+
+	numSpecialFilesSkipped int64
+}
+
+// Stats counts the work done by a Searcher.
+type Stats struct {
+	DirsRead  int64
+	FilesRead int64
+	BytesRead int64
+
+	// Pipes, sockets and devices skipped because of Options.Types.
+	SpecialFilesSkipped int64
+}
+
+// State of a single call to Search.
+type searchRun struct {
+	*Searcher
+	ctx        context.Context
+	cancel     context.CancelFunc
+	resultFunc ResultFunc
+	queue      *walkQueue
+
+	// Whether results are held back to be emitted in depth-first order.
+	isOrdered bool
+
+	// Guards calls to resultFunc and the first error.
+	resultMutex sync.Mutex
+	err         error
+
+	// With SearchDirs, the dirs whose entries are visited, and the dirs
+	// leading to them, all as cleaned paths. Both are nil otherwise.
+	withinDirs map[string]bool
+	towardDirs map[string]bool
+
+	// Paths of the files already searched, when following links or
+	// deduplicating files.
+	searchedMutex sync.Mutex
+	searchedFiles map[fileID]searchedFile
+}
+
+// The node that a file is searched under.
+type searchedFile struct {
+	path  string
+	order []int
+}
+
+// State of a single worker goroutine within a search.
+type searchWorker struct {
+	*searchRun
+	node         *walkNode
+	matchBuffers matchBuffers
+
+	// Buffers reused from file to file, for guessing the encoding and for
+	// reading lines.
+	sampleBuffer *bufio.Reader
+	lineBuffer   *bufio.Reader
+}
+
+// NewSearcher validates the options and prepares them for searching.
+func NewSearcher(options Options) (*Searcher, error) {
+	if options.Dir == "" {
+		options.Dir = "."
+	}
+	if options.ContextLines < 0 {
+		return nil, fmt.Errorf("invalid number of context lines: %v", options.ContextLines)
+	}
+	if options.SearchNamesOnly && options.SearchContentsOnly {
+		return nil, errors.New("cannot search names only and contents only at the same time")
+	}
+	if options.MinSize < 0 || options.MaxSize < 0 {
+		return nil, fmt.Errorf("invalid file size range: %v to %v", options.MinSize, options.MaxSize)
+	}
+	if options.MaxSize > 0 && options.MinSize > options.MaxSize {
+		return nil, fmt.Errorf("minimum file size %v is above maximum file size %v", options.MinSize, options.MaxSize)
+	}
+	if options.MaxLineLength < 0 {
+		return nil, fmt.Errorf("invalid maximum line length: %v", options.MaxLineLength)
+	}
+	if options.MaxLineLength == 0 {
+		options.MaxLineLength = DefaultMaxLineLength
+	}
+	if (options.LongLines < LongLinesChunk) || (options.LongLines > LongLinesSkip) {
+		return nil, fmt.Errorf("unknown long line policy: %v", options.LongLines)
+	}
+	if (options.Records < RecordsLines) || (options.Records > RecordsRegex) {
+		return nil, fmt.Errorf("unknown record separator: %v", options.Records)
+	}
+	if (options.Records == RecordsRegex) && (options.RecordRegex == "") {
+		return nil, errors.New("no regex given for the start of records")
+	}
+	if options.MinStringLength < 0 {
+		return nil, fmt.Errorf("invalid minimum string length: %v", options.MinStringLength)
+	}
+	if options.MinStringLength == 0 {
+		options.MinStringLength = DefaultMinStringLength
+	}
+	if options.Strings && (options.HexPatterns || options.Multiline || (options.Records != RecordsLines)) {
+		return nil, errors.New("cannot extract strings along with byte patterns, multiline mode or records")
+	}
+	if options.HexPatterns && (options.Multiline || (options.Records != RecordsLines) || options.InvertMatch) {
+		return nil, errors.New("cannot search byte patterns in multiline mode, in records or inverted")
+	}
+	if options.HexPatterns && options.SearchNamesOnly {
+		return nil, errors.New("cannot search names for byte patterns")
+	}
+	if options.HexPatterns {
+		options.SearchContentsOnly = true
+	}
+	if options.Multiline && (options.Records != RecordsLines) {
+		return nil, errors.New("cannot search records in multiline mode")
+	}
+	if options.Multiline && options.InvertMatch {
+		return nil, errors.New("cannot invert matches in multiline mode")
+	}
+	if (options.Encoding != "") && !IsKnownEncoding(options.Encoding) {
+		return nil, fmt.Errorf("unknown encoding %q", options.Encoding)
+	}
+	if (options.BinaryFiles < BinaryFilesSkip) || (options.BinaryFiles > BinaryFilesReport) {
+		return nil, fmt.Errorf("unknown binary file policy: %v", options.BinaryFiles)
+	}
+	if options.Jobs < 0 {
+		return nil, fmt.Errorf("invalid number of jobs: %v", options.Jobs)
+	}
+	if options.Jobs == 0 {
+		options.Jobs = runtime.NumCPU()
+	}
+	for _, fileType := range options.Types {
+		if !isKnownFileType(fileType) {
+			return nil, fmt.Errorf("unknown file type %q", rune(fileType))
+		}
+	}
+	if options.MaxArchiveDepth < 0 {
+		return nil, fmt.Errorf("invalid archive depth: %v", options.MaxArchiveDepth)
+	}
+	if options.MaxArchiveDepth == 0 {
+		options.MaxArchiveDepth = DefaultMaxArchiveDepth
+	}
+
+	m, err := newMatcher(&options)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Searcher{options: options, matcher: m, fsys: options.FS}
+	if options.Records == RecordsRegex {
+		if s.recordRegex, err = regexp.Compile(options.RecordRegex); err != nil {
+			return nil, fmt.Errorf("invalid record regex %q: %v", options.RecordRegex, err)
+		}
+	}
+	if s.includeKinds, err = newFileKindFilter(options.IncludeKinds); err != nil {
+		return nil, err
+	}
+	if s.excludeKinds, err = newFileKindFilter(options.ExcludeKinds); err != nil {
+		return nil, err
+	}
+
+	s.ignoreFileNames = options.IgnoreFileNames
+	if len(s.ignoreFileNames) == 0 {
+		s.ignoreFileNames = DefaultIgnoreFileNames
+	}
+	if s.fsys == nil {
+		s.fsys = OSFS()
+	}
+	s.joinPath = joinPathFuncFor(s.fsys)
+
+	// Only the local file system has mount points.
+	if _, ok := s.fsys.(osFS); ok && (len(options.ExcludeFSTypes) > 0) {
+		if s.excludedMountPoints, err = loadMountPoints(options.ExcludeFSTypes); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Options returns the options the Searcher was created with.
+func (s *Searcher) Options() Options {
+	return s.options
+}
+
+// Stats returns the totals for all searches run so far by this Searcher.
+func (s *Searcher) Stats() Stats {
+	return Stats{
+		DirsRead:  atomic.LoadInt64(&s.numDirsRead),
+		FilesRead: atomic.LoadInt64(&s.numFilesRead),
+		BytesRead: atomic.LoadInt64(&s.numBytesRead),
+
+		SpecialFilesSkipped: atomic.LoadInt64(&s.numSpecialFilesSkipped),
+	}
+}
+
+// Search walks the starting dir and calls resultFunc for each result.
+// Dirs are read and files are scanned by Options.Jobs goroutines, but
+// resultFunc is never called concurrently, and unless Options.Unordered is
+// set, the results arrive in depth-first order, the same as a single
+// goroutine would find them. Search returns when the whole tree has been
+// searched, when resultFunc returns an error, or when ctx is done.
+func (s *Searcher) Search(ctx context.Context, resultFunc ResultFunc) error {
+	roots := s.options.Roots
+	if roots == nil && s.options.Paths == nil {
+		roots = []string{s.options.Dir}
+	}
+	return s.search(ctx, roots, s.options.Paths, true, nil, resultFunc)
+}
+
+// SearchDirs is like Search, but only visits the dirs and files directly
+// within the given dirs, as Search would find them, walking into the dirs
+// leading to them without visiting those. This is meant for listing again
+// the dirs whose entries have changed.
+func (s *Searcher) SearchDirs(ctx context.Context, dirs []string, resultFunc ResultFunc) error {
+	roots := s.options.Roots
+	if roots == nil && s.options.Paths == nil {
+		roots = []string{s.options.Dir}
+	}
+	return s.search(ctx, roots, s.options.Paths, true, dirs, resultFunc)
+}
+
+// SearchPaths searches the given dirs and files on their own, like the
+// ones in Options.Paths, but without applying any filters. This is meant
+// for searching again the files found by an earlier search, e.g. after
+// they have changed.
+func (s *Searcher) SearchPaths(ctx context.Context, paths []string, resultFunc ResultFunc) error {
+	return s.search(ctx, nil, paths, false, nil, resultFunc)
+}
+
+func (s *Searcher) search(ctx context.Context, roots, paths []string, filterPaths bool, dirs []string,
+	resultFunc ResultFunc) error {
+
+	rootInfos := make([]fs.FileInfo, len(roots))
+	for i, root := range roots {
+		fileInfo, err := fs.Stat(s.fsys, root)
+		if err != nil {
+			return fmt.Errorf("cannot read starting dir or file %q: %v", root, err)
+		}
+		rootInfos[i] = fileInfo
+	}
+
+	run := s.newSearchRun(ctx, resultFunc)
+	defer run.cancel()
+	if dirs != nil {
+		run.setWithinDirs(dirs)
+	}
+
+	// Wake up idle workers when the search is cancelled.
+	go func() {
+		<-run.ctx.Done()
+		run.queue.close()
+	}()
+
+	// The roots and listed paths are the children of a top node, which
+	// has nothing to search itself.
+	top := newWalkNode(nil, -1, "", "", nil, nil)
+	close(top.done)
+
+	for i, root := range roots {
+		fileInfo := rootInfos[i]
+		if !fileInfo.IsDir() {
+			run.addStartingFile(top, root, fileInfo, false, true)
+			continue
+		}
+
+		var ignores *ignoreList
+		if s.options.UseIgnoreFiles {
+			ignores = s.loadStartingIgnoreFiles(root)
+		}
+		run.addStartingNode(top, newWalkNode(fileInfo, -1, root, "", ignores, top))
+	}
+
+	for _, listedPath := range paths {
+		if !run.isEntryWithinDirs(listedPath) {
+			continue
+		}
+		fileInfo, err := fs.Stat(s.fsys, listedPath)
+		if err != nil {
+			run.reportError(listedPath, err)
+			continue
+		}
+		run.addStartingFile(top, listedPath, fileInfo, true, filterPaths)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.options.Jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run.runWorker()
+		}()
+	}
+
+	if run.isOrdered {
+		run.emitResults(top)
+	}
+	wg.Wait()
+
+	return run.finalError(ctx)
+}
+
+// SearchReader searches the contents of a reader as if it were a file with
+// the given name, e.g. "(stdin)" for the standard input. There are no dir
+// or file names to match, and results are passed on as soon as they are
+// found, so that a stream can be searched while it is being written.
+func (s *Searcher) SearchReader(ctx context.Context, name string, reader io.Reader, resultFunc ResultFunc) error {
+	if s.options.ListAll || s.options.SearchNamesOnly {
+		return nil
+	}
+
+	run := s.newSearchRun(ctx, resultFunc)
+	run.isOrdered = false
+	defer run.cancel()
+
+	atomic.AddInt64(&s.numFilesRead, 1)
+	w := &searchWorker{searchRun: run}
+	w.searchContents(name, reader)
+
+	return run.finalError(ctx)
+}
+
+func (s *Searcher) newSearchRun(ctx context.Context, resultFunc ResultFunc) *searchRun {
+	run := &searchRun{
+		Searcher:   s,
+		resultFunc: resultFunc,
+		queue:      newWalkQueue(),
+		isOrdered:  !s.options.Unordered,
+	}
+	if s.options.FollowLinks || s.options.DedupeFiles {
+		run.searchedFiles = make(map[fileID]searchedFile)
+	}
+	run.ctx, run.cancel = context.WithCancel(ctx)
+	return run
+}
+
+func (run *searchRun) setWithinDirs(dirs []string) {
+	run.withinDirs = make(map[string]bool)
+	run.towardDirs = make(map[string]bool)
+	for _, dir := range dirs {
+		dir = run.joinPath(dir)
+		run.withinDirs[dir] = true
+		for {
+			parent := run.joinPath(dir, "..")
+			if (parent == dir) || run.towardDirs[parent] || (path.Base(filepath.ToSlash(parent)) == "..") {
+				break
+			}
+			run.towardDirs[parent] = true
+			dir = parent
+		}
+	}
+}
+
+// Tells whether the entries of the dir are visited.
+func (run *searchRun) isWithinDirs(dirPath string) bool {
+	return (run.withinDirs == nil) || run.withinDirs[run.joinPath(dirPath)]
+}
+
+func (run *searchRun) isEntryWithinDirs(path string) bool {
+	return (run.withinDirs == nil) || run.withinDirs[run.joinPath(path, "..")]
+}
+
+// Tells whether the cleaned path is walked into to get to the dirs whose
+// entries are visited.
+func (run *searchRun) isTowardDirs(path string) bool {
+	return run.withinDirs[path] || run.towardDirs[path]
+}
+
+func (run *searchRun) finalError(ctx context.Context) error {
+	if run.err == nil {
+		// Only the caller's context could have been cancelled.
+		return ctx.Err()
+	}
+	if run.err == StopSearch {
+		return nil
+	}
+	return run.err
+}
+
+// Dirs and files given on their own are searched as long as they pass the
+// name, size and time filters, but ignore files do not apply to them.
+func (run *searchRun) addStartingFile(top *walkNode, filePath string, fileInfo fs.FileInfo, isListed, applyFilters bool) {
+	relPath := path.Clean(filepath.ToSlash(filePath))
+	depth := 0
+	if !isListed {
+		relPath = path.Base(relPath)
+	} else if rel, ok := run.relPathOfListedPath(filePath); ok {
+		// Listed paths are filtered as if they were found by walking.
+		relPath = rel
+		depth = strings.Count(relPath, "/")
+		if applyFilters && !run.shouldIncludeListedPath(relPath, depth) {
+			return
+		}
+	}
+	if applyFilters && !run.shouldInclude(filePath, fileInfo, relPath, 0) {
+		return
+	}
+
+	node := newWalkNode(fileInfo, depth, filePath, relPath, nil, top)
+	node.isListed = isListed
+	run.addStartingNode(top, node)
+}
+
+// Returns the path relative to the deepest of Options.PathRoots and Dir
+// holding it.
+func (run *searchRun) relPathOfListedPath(filePath string) (string, bool) {
+	relPath, found := "", false
+	for _, dir := range append([]string{run.options.Dir}, run.options.PathRoots...) {
+		rel, err := filepath.Rel(dir, filePath)
+		if (err != nil) || (rel == "..") || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !found || (len(rel) < len(relPath)) {
+			relPath, found = filepath.ToSlash(rel), true
+		}
+	}
+	return relPath, found
+}
+
+// Applies the depth limit and the dir filters to each dir above a listed
+// path, as when walking down to it.
+func (run *searchRun) shouldIncludeListedPath(relPath string, depth int) bool {
+	if (run.options.MaxLevels >= 0) && (depth > run.options.MaxLevels) {
+		return false
+	}
+	for pos := strings.IndexByte(relPath, '/'); pos >= 0; pos = nextSlash(relPath, pos) {
+		dirPath := relPath[:pos]
+		if !run.matcher.shouldIncludeDirByNameFilters(path.Base(dirPath), dirPath) {
+			return false
+		}
+	}
+	return true
+}
+
+func (run *searchRun) addStartingNode(top *walkNode, node *walkNode) {
+	run.identify(node)
+	top.children = append(top.children, node)
+	run.queue.push(node)
+}
+
+// Records the first error and stops the search.
+func (run *searchRun) fail(err error) {
+	if run.err == nil {
+		run.err = err
+	}
+	run.cancel()
+}
+
+func (run *searchRun) isCancelled() bool {
+	select {
+	case <-run.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// Results are kept with the node until they can be emitted in order,
+// or passed on right away in unordered mode.
+func (w *searchWorker) report(result *Result) error {
+	if w.isOrdered {
+		w.node.results = append(w.node.results, result)
+		return nil
+	}
+
+	w.resultMutex.Lock()
+	defer w.resultMutex.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.resultFunc(result); err != nil {
+		w.fail(err)
+		return err
+	}
+	return nil
+}
+
+func (run *searchRun) reportError(path string, err error) {
+	if run.options.OnError != nil {
+		run.resultMutex.Lock()
+		run.options.OnError(path, err)
+		run.resultMutex.Unlock()
+	}
+}
+
+/**************************************************************************/
+
+// Search directory tree traversal.
+
+func (run *searchRun) runWorker() {
+	w := &searchWorker{searchRun: run}
+	for {
+		node, ok := run.queue.pop()
+		if !ok {
+			return
+		}
+		if run.isCancelled() {
+			// Nothing to do.
+		} else if run.isAlreadySearched(node, false) {
+			// The emitter reports the duplicates in ordered mode, including
+			// the nodes searched before an earlier one claimed the file.
+			if !run.isOrdered && run.options.ReportDuplicates {
+				w.report(run.newDuplicateResult(node))
+			}
+		} else {
+			w.node = node
+			w.searchNode(node)
+			w.node = nil
+		}
+		close(node.done)
+		run.queue.finish()
+	}
+}
+
+// Visits the node and queues its children.
+func (w *searchWorker) searchNode(node *walkNode) {
+	// Dirs are walked even when only other types are wanted.
+	if (node.depth >= 0) && w.isEntryWithinDirs(node.path) && w.isWantedType(fileTypeOf(node.fileInfo.Mode())) {
+		if err := w.visitFileOrDir(node.path, node.relPath, node.fileInfo); err != nil {
+			return
+		}
+	}
+
+	// Check max dir depth.
+	if (w.options.MaxLevels >= 0) && (node.depth >= w.options.MaxLevels) {
+		return
+	}
+
+	// Get list of subdirs and apply inclusion/exclusion filters.
+	if !node.fileInfo.IsDir() || node.isListed {
+		return
+	}
+	isWithinDirs := w.isWithinDirs(node.path)
+	if !isWithinDirs && !w.isTowardDirs(w.joinPath(node.path)) {
+		return
+	}
+
+	entries, err := fs.ReadDir(w.fsys, node.path)
+	if err != nil {
+		w.reportError(node.path, err)
+		return
+	}
+
+	ignores := node.ignores
+	if w.options.UseIgnoreFiles {
+		ignores = w.loadIgnoreFiles(node, entries)
+	}
+
+	newDepth := node.depth + 1
+	for _, entry := range entries {
+		newPath := w.joinPath(node.path, entry.Name())
+		if !isWithinDirs && !w.isTowardDirs(newPath) {
+			continue
+		}
+		newRelPath := path.Join(node.relPath, entry.Name())
+
+		fileInfo, err := lstat(w.fsys, newPath, entry)
+		if err != nil {
+			w.reportError(newPath, err)
+			continue
+		}
+		isLink := ((fileInfo.Mode() | entry.Type()) & fs.ModeSymlink) == fs.ModeSymlink
+
+		if w.options.UseIgnoreFiles && w.isIgnored(newPath, newRelPath, fileInfo, ignores) {
+			continue
+		}
+
+		// Don't follow symbolic links unless asked to, or unless the
+		// links themselves are wanted.
+		if isLink && !w.isWantedLink() {
+			if fileInfo = w.followLink(newPath, node); fileInfo == nil {
+				continue
+			}
+		}
+
+		if !w.shouldInclude(newPath, fileInfo, newRelPath, 0) {
+			continue
+		}
+
+		if fileInfo.IsDir() && w.isPrunedMount(newPath, fileInfo, node) {
+			continue
+		}
+		child := newWalkNode(fileInfo, newDepth, newPath, newRelPath, ignores, node)
+		w.identify(child)
+		node.children = append(node.children, child)
+	}
+
+	for _, child := range node.children {
+		w.queue.push(child)
+	}
+}
+
+// Checks the ignore files, reporting the ignored dir or file if needed.
+func (w *searchWorker) isIgnored(path, relPath string, fileInfo fs.FileInfo, ignores *ignoreList) bool {
+	isDir := fileInfo.IsDir()
+
+	// Like git, never look inside the repository's own dir.
+	if isDir && fileInfo.Name() == gitDirName {
+		return true
+	}
+
+	ignored, reason := ignores.isIgnored(relPath, isDir)
+	if ignored && w.options.ReportIgnored {
+		w.report(&Result{Kind: ResultIgnored, Path: path, IsDir: isDir, Text: reason})
+	}
+	return ignored
+}
+
+// Returns the target of the link, or nil if it should not be followed.
+// Broken links are reported even when links are not followed.
+func (w *searchWorker) followLink(path string, parent *walkNode) fs.FileInfo {
+	if !w.options.FollowLinks && !w.options.ReportBrokenLinks {
+		return nil
+	}
+
+	fileInfo, err := fs.Stat(w.fsys, path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			if w.options.ReportBrokenLinks {
+				w.report(&Result{Kind: ResultBrokenLink, Path: path, Text: readLink(w.fsys, path)})
+			}
+		} else if w.options.FollowLinks {
+			w.reportError(path, err)
+		}
+		return nil
+	}
+
+	if !w.options.FollowLinks {
+		return nil
+	}
+
+	// Don't loop forever on a link to a parent dir.
+	if fileInfo.IsDir() && parent.isWithinDir(fileInfo) {
+		return nil
+	}
+	return fileInfo
+}
+
+// Files can only be told apart by their identity when following links or
+// deduplicating files.
+func (run *searchRun) identify(node *walkNode) {
+	if run.searchedFiles != nil {
+		node.id, node.hasID = getFileID(node.fileInfo)
+	}
+}
+
+// Tells whether the file is searched under another path, so that each file
+// is searched once however many links or mounts lead to it. The first
+// worker to get to a dir or file claims it, and the emitter skips the nodes
+// that did not. With an emitter, a file is taken over by any node before it
+// in depth-first order, so that its first path is kept. Dirs are never
+// taken over, since the files within them have been claimed already; as
+// workers take nodes in depth-first order, a dir also keeps its first path
+// when there is a single worker.
+func (run *searchRun) isAlreadySearched(node *walkNode, isEmitting bool) bool {
+	if !node.hasID {
+		return false
+	}
+
+	run.searchedMutex.Lock()
+	defer run.searchedMutex.Unlock()
+
+	searched, ok := run.searchedFiles[node.id]
+	if isEmitting {
+		return ok && !isSameOrder(searched.order, node.order)
+	}
+	if ok && (!run.isOrdered || node.fileInfo.IsDir() || !isOrderBefore(node.order, searched.order)) {
+		return true
+	}
+	run.searchedFiles[node.id] = searchedFile{path: node.path, order: node.order}
+	return false
+}
+
+func (run *searchRun) newDuplicateResult(node *walkNode) *Result {
+	run.searchedMutex.Lock()
+	defer run.searchedMutex.Unlock()
+
+	return &Result{
+		Kind:  ResultDuplicate,
+		Path:  node.path,
+		IsDir: node.fileInfo.IsDir(),
+		Text:  run.searchedFiles[node.id].path,
+	}
+}
+
+// The file path is only used to look into the file when needed, and may be
+// empty for the entries of archives.
+func (run *searchRun) shouldInclude(filePath string, fileInfo fs.FileInfo, relPath string, archiveDepth int) bool {
+	if fileInfo.IsDir() {
+		return run.matcher.shouldIncludeDirByNameFilters(fileInfo.Name(), relPath)
+	}
+
+	if fileType := fileTypeOf(fileInfo.Mode()); isSpecialFileType(fileType) && !run.isWantedType(fileType) {
+		atomic.AddInt64(&run.numSpecialFilesSkipped, 1)
+		return false
+	}
+
+	if run.archiveFormatOf(fileInfo.Name(), archiveDepth) != notArchive {
+		return !run.isSkippedFile(fileInfo) &&
+			run.matcher.shouldIncludeArchiveByNameFilters(fileInfo.Name(), relPath)
+	}
+
+	return !run.isSkippedFile(fileInfo) &&
+		run.matcher.shouldIncludeFileByNameFilters(fileInfo.Name(), relPath) &&
+		run.shouldIncludeFileBySizeAndTime(fileInfo) &&
+		run.shouldIncludeFileByKinds(filePath, fileInfo, relPath)
+}
+
+func (run *searchRun) shouldIncludeFileBySizeAndTime(fileInfo fs.FileInfo) bool {
+	size := fileInfo.Size()
+	if size < run.options.MinSize {
+		return false
+	}
+	if (run.options.MaxSize > 0) && (size > run.options.MaxSize) {
+		return false
+	}
+
+	modTime := fileInfo.ModTime()
+	if !run.options.NewerThan.IsZero() && !modTime.After(run.options.NewerThan) {
+		return false
+	}
+	if !run.options.OlderThan.IsZero() && !modTime.Before(run.options.OlderThan) {
+		return false
+	}
+	return true
+}
+
+func (run *searchRun) isSkippedFile(fileInfo fs.FileInfo) bool {
+	for _, skipFileInfo := range run.options.SkipFiles {
+		if (skipFileInfo != nil) && os.SameFile(skipFileInfo, fileInfo) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *searchWorker) visitFileOrDir(path, relPath string, fileInfo fs.FileInfo) error {
+	format := w.archiveFormatOf(fileInfo.Name(), 0)
+	showName := (format == notArchive) || w.matcher.shouldIncludeFileByNameFilters(fileInfo.Name(), relPath)
+	searchContents, err := w.visitName(path, fileInfo, format, showName)
+	if !searchContents || (err != nil) {
+		return err
+	}
+
+	// Only regular files have contents to search.
+	if !fileInfo.Mode().IsRegular() {
+		return nil
+	}
+
+	if format != notArchive {
+		return w.searchArchiveFile(path, relPath, format)
+	}
+	return w.searchFileContents(path)
+}
+
+// Counts the dir or file and lists or matches its name. Tells whether its
+// contents should be searched next, which for an archive means searching
+// its entries. An archive that does not match the include filters is only
+// searched for its entries, without showing its own name.
+func (w *searchWorker) visitName(path string, fileInfo fs.FileInfo, format archiveFormat, showName bool) (bool, error) {
+	isDir := fileInfo.IsDir()
+	if isDir {
+		atomic.AddInt64(&w.numDirsRead, 1)
+	} else {
+		atomic.AddInt64(&w.numFilesRead, 1)
+	}
+
+	isArchive := (format != notArchive)
+	if !showName {
+		return true, nil
+	}
+
+	if w.options.ListAll {
+		err := w.report(&Result{Kind: ResultEntry, Path: path, IsDir: isDir, Info: fileInfo})
+		return isArchive && (err == nil), err
+	}
+
+	if !w.options.SearchContentsOnly {
+		matched, err := w.searchPathName(path, isDir)
+		if err != nil {
+			return false, err
+		}
+
+		// Don't double-print the same filename.
+		// The side effect of this is that once the dir or file name matches,
+		// we will not show the match count within the file content:
+		// e.g. ff -2 txt
+		if w.options.CountOnly && matched && !isArchive {
+			return false, nil
+		}
+	}
+
+	return !isDir && (isArchive || !w.options.SearchNamesOnly), nil
+}
+
+/**************************************************************************/
+
+// Searching file and dir names.
+
+func (w *searchWorker) searchPathName(path string, isDir bool) (bool, error) {
+	baseName := filepath.Base(path)
+
+	// Check for match.
+	spans, matched := w.matcher.matchLine(baseName, &w.matchBuffers)
+	if matched == w.options.InvertMatch {
+		return false, nil
+	}
+
+	result := &Result{
+		Kind:  ResultName,
+		Path:  path,
+		IsDir: isDir,
+		Text:  baseName,
+	}
+	if !w.options.InvertMatch {
+		result.Spans = append([]Span(nil), spans...)
+	}
+	return true, w.report(result)
+}
+
+/**************************************************************************/
+
+// Searching within files.
+
+func (w *searchWorker) searchFileContents(path string) error {
+	// Open file for reading.
+	fileHandle, err := w.fsys.Open(path)
+	if err != nil {
+		// This output is usually unnecessary, but cannot be sure.
+		w.reportError(path, err)
+		return nil
+	}
+	defer fileHandle.Close()
+
+	return w.searchContents(path, fileHandle)
+}
+
+func (w *searchWorker) searchContents(path string, file io.Reader) error {
+	if w.options.Decompress {
+		decompressed, err := decompressIfNeeded(file)
+		if err != nil {
+			w.reportError(path, err)
+			return nil
+		}
+		file = decompressed
+	}
+
+	if w.options.HexPatterns {
+		return w.searchFileBytes(path, file)
+	}
+
+	// Strings are extracted from the raw bytes of any file.
+	encoding, isBinary := "", false
+	if !w.options.Strings {
+		file, encoding, isBinary = decodeText(w.buffered(&w.sampleBuffer, file, encodingSampleSize),
+			w.options.Encoding, (w.options.Records == RecordsNUL))
+		if isBinary && (w.options.BinaryFiles == BinaryFilesSkip) {
+			return nil
+		}
+	}
+
+	if w.options.Multiline {
+		return w.searchMultilineContents(path, encoding, file, isBinary)
+	}
+
+	reader := newLineReader(w.buffered(&w.lineBuffer, file, lineReaderBufferSize), &w.options, w.recordRegex)
+	defer func() {
+		atomic.AddInt64(&w.numBytesRead, reader.numBytesRead)
+
+		// Read errors end the file early.
+		if reader.err != nil {
+			w.reportError(path, reader.err)
+		}
+	}()
+
+	// If file is empty, return.
+	if !reader.next() {
+		return nil
+	}
+
+	if isBinary && (w.options.BinaryFiles == BinaryFilesReport) {
+		return w.searchBinaryFileContents(path, reader)
+	}
+
+	// If we want to count matches only, then we do something special.
+	if w.options.CountOnly {
+		return w.searchFileContentsForCountOnly(path, encoding, reader)
+	}
+
+	// Normal search through each line in the file.
+	for !w.isCancelled() {
+		spans, matched := w.matcher.matchLine(reader.line, &w.matchBuffers)
+
+		// Empty lines never match, even when inverted.
+		if reader.line != "" && matched != w.options.InvertMatch {
+			result := &Result{
+				Kind:       ResultLine,
+				Path:       path,
+				LineNumber: reader.lineNumber,
+				Text:       reader.line,
+				Encoding:   encoding,
+				Before:     reader.before(),
+				After:      reader.after(),
+			}
+			if !w.options.InvertMatch {
+				result.Spans = append([]Span(nil), spans...)
+				result.Column = reader.column + columnOfFirstSpan(reader.line, spans)
+				result.EndLineNumber = reader.lineNumber
+				result.EndColumn = reader.column + columnOfLastCharOfFirstSpan(reader.line, spans)
+			}
+			if w.options.Strings {
+				result.Offset = reader.offset
+			}
+
+			if err := w.report(result); err != nil {
+				return err
+			}
+		}
+
+		if !reader.next() {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Returns the buffer reading from the file, allocating it for the first
+// file only.
+func (w *searchWorker) buffered(buffer **bufio.Reader, file io.Reader, size int) *bufio.Reader {
+	if *buffer == nil {
+		*buffer = bufio.NewReaderSize(nil, size)
+	}
+	(*buffer).Reset(file)
+	return *buffer
+}
+
+// Returns the 1-indexed column of the leftmost match, counted in runes.
+func columnOfFirstSpan(line string, spans []Span) int {
+	if len(spans) == 0 {
+		return 0
+	}
+	return columnAt(line, firstSpanBegin(spans))
+}
+
+// Returns the 1-indexed column of the last character of the leftmost match.
+func columnOfLastCharOfFirstSpan(line string, spans []Span) int {
+	if len(spans) == 0 {
+		return 0
+	}
+	first := spans[0]
+	for _, span := range spans[1:] {
+		if span.Begin < first.Begin {
+			first = span
+		}
+	}
+	_, size := utf8.DecodeLastRuneInString(line[:first.End])
+	return columnAt(line, first.End-size)
+}
+
+func columnAt(line string, offset int) int {
+	column := 1
+	for pos := range line {
+		if pos >= offset {
+			break
+		}
+		column++
+	}
+	return column
+}
+
+func firstSpanBegin(spans []Span) int {
+	minBegin := spans[0].Begin
+	for _, span := range spans[1:] {
+		if span.Begin < minBegin {
+			minBegin = span.Begin
+		}
+	}
+	return minBegin
+}
+
+// Reports the first match only, since the lines of binary files are
+// meaningless.
+func (w *searchWorker) searchBinaryFileContents(path string, reader *lineReader) error {
+	for !w.isCancelled() {
+		spans, matched := w.matcher.matchLine(reader.line, &w.matchBuffers)
+		if reader.line != "" && matched != w.options.InvertMatch {
+			offset := reader.offset
+			if !w.options.InvertMatch {
+				offset += int64(firstSpanBegin(spans))
+			}
+			return w.report(&Result{Kind: ResultBinaryFile, Path: path, Offset: offset})
+		}
+
+		if !reader.next() {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (w *searchWorker) searchFileContentsForCountOnly(path, encoding string, reader *lineReader) error {
+	numMatches := 0
+
+	for !w.isCancelled() {
+		if w.matcher.isLineMatching(reader.line, &w.matchBuffers) {
+			// We could have just stopped after the first match, but reporting the
+			// total number of matches provides a better user experience.
+			numMatches++
+		}
+
+		if !reader.next() {
+			break
+		}
+	}
+
+	// Return if not matching, or if the count is incomplete.
+	if w.isCancelled() {
+		return nil
+	}
+	hadMatch := (numMatches > 0)
+	if hadMatch == w.options.InvertMatch {
+		return nil
+	}
+
+	return w.report(&Result{Kind: ResultFile, Path: path, NumMatches: numMatches, Encoding: encoding})
+}
+
+/**************************************************************************/
@@ -0,0 +1,337 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Searching file systems in memory.
+
+// Options that search the whole file system in memory.
+func newTestOptions(fsys fs.FS) Options {
+	options := DefaultOptions()
+	options.FS = fsys
+	return options
+}
+
+// Searches with the options, failing the test on any error.
+func searchForTest(t *testing.T, options Options) []*Result {
+	t.Helper()
+	options.OnError = func(path string, err error) {
+		t.Errorf("%v: %v", path, err)
+	}
+	searcher, err := NewSearcher(options)
+	if err != nil {
+		t.Fatalf("NewSearcher: %v", err)
+	}
+
+	var results []*Result
+	err = searcher.Search(context.Background(), func(result *Result) error {
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	return results
+}
+
+// Searches with the options, returning the paths passed to OnError, which
+// are sorted, along with the results.
+func searchWithErrorsForTest(t *testing.T, options Options) ([]*Result, []string) {
+	t.Helper()
+	errorPaths := []string{}
+	options.OnError = func(path string, err error) {
+		errorPaths = append(errorPaths, path)
+	}
+	searcher, err := NewSearcher(options)
+	if err != nil {
+		t.Fatalf("NewSearcher: %v", err)
+	}
+
+	var results []*Result
+	err = searcher.Search(context.Background(), func(result *Result) error {
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	sort.Strings(errorPaths)
+	return results, errorPaths
+}
+
+// Returns the sorted paths of the results of the given kind.
+func resultPaths(results []*Result, kind ResultKind) []string {
+	paths := []string{}
+	for _, result := range results {
+		if result.Kind == kind {
+			paths = append(paths, result.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestListedPathsAreFiltered(t *testing.T) {
+	fsys := fstest.MapFS{
+		"top.go":          {Data: []byte("package top\n")},
+		"src/a.go":        {Data: []byte("package src\n")},
+		"src/x/test/t.go": {Data: []byte("package test\n")},
+	}
+	options := newTestOptions(fsys)
+	options.ListAll = true
+	options.Paths = []string{"top.go", "src/a.go", "src/x/test/t.go"}
+	options.ExcludeDirs = []string{"x"}
+
+	got := resultPaths(searchForTest(t, options), ResultEntry)
+	want := []string{"src/a.go", "top.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSearchContentsInOrder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"b.txt":     {Data: []byte("needle b\n")},
+		"a/z.txt":   {Data: []byte("hay\nneedle az\n")},
+		"a/b/c.txt": {Data: []byte("needle abc\n")},
+		"c.txt":     {Data: []byte("nothing\n")},
+	}
+	options := newTestOptions(fsys)
+	options.SearchStrings = []string{"needle"}
+	options.Jobs = 4
+
+	var got []string
+	for _, result := range searchForTest(t, options) {
+		if result.Kind == ResultLine {
+			got = append(got, result.Text)
+		}
+	}
+	want := []string{"needle abc", "needle az", "needle b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLinksFromDirEntries(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dir/a.txt": {Data: []byte("needle\n")},
+		"link":      {Data: []byte("dir"), Mode: fs.ModeSymlink},
+	}
+	for _, followLinks := range []bool{false, true} {
+		options := newTestOptions(fsys)
+		options.SearchStrings = []string{"needle"}
+		options.FollowLinks = followLinks
+
+		got := resultPaths(searchForTest(t, options), ResultLine)
+		want := []string{"dir/a.txt"}
+		if followLinks {
+			want = append(want, "link/a.txt")
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("following links %v: got %q, want %q", followLinks, got, want)
+		}
+	}
+}
+
+func TestDedupeFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b/c", "d"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := filepath.Join(dir, "a", "x.txt")
+	if err := os.WriteFile(first, []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := []string{filepath.Join(dir, "b", "c", "y.txt"), filepath.Join(dir, "d", "z.txt")}
+	for _, link := range links {
+		if err := os.Link(first, link); err != nil {
+			t.Skipf("cannot make hard links: %v", err)
+		}
+	}
+	if fileInfo, err := os.Stat(first); (err != nil) || !hasFileIDForTest(fileInfo) {
+		t.Skip("no file IDs on this platform")
+	}
+
+	for _, unordered := range []bool{false, true} {
+		options := newTestOptions(nil)
+		options.Dir = dir
+		options.SearchStrings = []string{"needle"}
+		options.DedupeFiles = true
+		options.ReportDuplicates = true
+		options.Jobs = 4
+		options.Unordered = unordered
+
+		results := searchForTest(t, options)
+		searched := resultPaths(results, ResultLine)
+		duplicates := resultPaths(results, ResultDuplicate)
+		if unordered {
+			if (len(searched) != 1) || (len(duplicates) != 2) {
+				t.Errorf("unordered: searched %q, duplicates %q", searched, duplicates)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(searched, []string{first}) || !reflect.DeepEqual(duplicates, links) {
+			t.Errorf("searched %q, duplicates %q", searched, duplicates)
+		}
+		for _, result := range results {
+			if (result.Kind == ResultDuplicate) && (result.Text != first) {
+				t.Errorf("%v: duplicate of %q", result.Path, result.Text)
+			}
+		}
+	}
+}
+
+func hasFileIDForTest(fileInfo fs.FileInfo) bool {
+	_, ok := getFileID(fileInfo)
+	return ok
+}
+
+func TestMatchColumns(t *testing.T) {
+	// Ⱥ takes 2 bytes and its lowercase ⱥ takes 3.
+	fsys := fstest.MapFS{"a.txt": {Data: []byte("ȺȺȺ abc\nx ȺȺ\n")}}
+	tests := []struct {
+		searchString string
+		regex        bool
+		want         []int
+	}{
+		{"ABC", false, []int{1, 5, 1, 7}},
+		{"abc", true, []int{1, 5, 1, 7}},
+		{"ⱥⱥ", false, []int{1, 1, 1, 2, 2, 3, 2, 4}},
+		{"ⱥ+", true, []int{1, 1, 1, 3, 2, 3, 2, 4}},
+	}
+	for _, test := range tests {
+		options := newTestOptions(fsys)
+		options.SearchStrings = []string{test.searchString}
+		options.IgnoreCase = true
+		options.Regex = test.regex
+
+		var got []int
+		for _, result := range searchForTest(t, options) {
+			if result.Kind == ResultLine {
+				got = append(got, result.LineNumber, result.Column, result.EndLineNumber, result.EndColumn)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.searchString, got, test.want)
+		}
+	}
+}
+
+func TestListAll(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":     {},
+		"dir/b.txt": {},
+		"dir/c/d":   {},
+	}
+	options := newTestOptions(fsys)
+	options.ListAll = true
+
+	got := resultPaths(searchForTest(t, options), ResultEntry)
+	want := []string{"a.txt", "dir", "dir/b.txt", "dir/c", "dir/c/d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+/**************************************************************************/
+
+// Unreadable dirs and files.
+
+// A file system in memory where some dirs and files cannot be read.
+type failingFS struct {
+	fstest.MapFS
+	failing map[string]bool
+}
+
+func (fsys failingFS) Open(name string) (fs.File, error) {
+	if fsys.failing[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return fsys.MapFS.Open(name)
+}
+
+func (fsys failingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if fsys.failing[name] {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+	return fsys.MapFS.ReadDir(name)
+}
+
+func failingFSForTest() failingFS {
+	return failingFS{
+		MapFS: fstest.MapFS{
+			"locked/secret.txt": {Data: []byte("hello\n")},
+			"open/a.txt":        {Data: []byte("hello\n")},
+			"open/b.txt":        {Data: []byte("hello\n")},
+		},
+		failing: map[string]bool{"locked": true, "open/b.txt": true},
+	}
+}
+
+// Each dir and file that cannot be read is reported, and the search goes
+// on with the others.
+func TestSearchErrors(t *testing.T) {
+	options := newTestOptions(failingFSForTest())
+	options.SearchStrings = []string{"hello"}
+	options.SearchContentsOnly = true
+
+	results, errorPaths := searchWithErrorsForTest(t, options)
+	if want := []string{"locked", "open/b.txt"}; !reflect.DeepEqual(errorPaths, want) {
+		t.Errorf("got errors for %q, want %q", errorPaths, want)
+	}
+	if got, want := resultPaths(results, ResultLine), []string{"open/a.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	options := newTestOptions(failingFSForTest())
+	options.ListAll = true
+
+	results, errorPaths := searchWithErrorsForTest(t, options)
+	if want := []string{"locked"}; !reflect.DeepEqual(errorPaths, want) {
+		t.Errorf("got errors for %q, want %q", errorPaths, want)
+	}
+	want := []string{"locked", "open", "open/a.txt", "open/b.txt"}
+	if got := resultPaths(results, ResultEntry); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+/**************************************************************************/
@@ -0,0 +1,195 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"io"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+/**************************************************************************/
+
+// Extracting strings.
+
+// DefaultMinStringLength is the number of characters in the shortest
+// strings extracted with Options.Strings, when Options.MinStringLength is 0.
+const DefaultMinStringLength = 4
+
+// Size of the reads from the file being extracted.
+const stringsReadSize = 64 * 1024
+
+// A run of printable characters being extracted, as UTF-8.
+type stringRun struct {
+	text   []byte
+	offset int64
+	length int
+}
+
+// State of the extraction of strings from a file, like the strings tool
+// does. UTF-8 runs and UTF-16LE runs at even and odd offsets are extracted
+// at the same time, and returned in the order of their offsets.
+type stringExtractor struct {
+	minLength int
+
+	// Bytes read but not stepped through yet, and the offset of the first.
+	block       []byte
+	blockOffset int64
+
+	// Offset of the next UTF-8 character, after the one stepped through.
+	nextUTF8Offset int64
+
+	utf8Run   stringRun
+	utf16Runs [2]stringRun
+
+	// Strings found but not returned yet, sorted by offset.
+	found      []lineChunk
+	numStrings int
+}
+
+func newStringExtractor(minLength int) *stringExtractor {
+	return &stringExtractor{minLength: minLength}
+}
+
+// Reads the next string from the file, numbered as if it were a line.
+func (lr *lineReader) readString() (lineChunk, bool) {
+	se := lr.strings
+	for {
+		// A string is only returned once no run still going started before it.
+		isEnded := lr.isFileEnded || (lr.err != nil)
+		if (len(se.found) > 0) && (isEnded || (se.found[0].offset < se.minRunOffset())) {
+			chunk := se.found[0]
+			se.found = se.found[1:]
+			se.numStrings++
+			chunk.Number = se.numStrings
+			return chunk, true
+		}
+		if isEnded {
+			return lineChunk{}, false
+		}
+		lr.readStringBlock()
+	}
+}
+
+func (se *stringExtractor) minRunOffset() int64 {
+	minOffset := int64(math.MaxInt64)
+	for _, run := range []*stringRun{&se.utf8Run, &se.utf16Runs[0], &se.utf16Runs[1]} {
+		if (run.length > 0) && (run.offset < minOffset) {
+			minOffset = run.offset
+		}
+	}
+	return minOffset
+}
+
+// Reads more of the file, and steps through the bytes read, keeping the
+// last few when a character may be cut in two.
+func (lr *lineReader) readStringBlock() {
+	se := lr.strings
+	start := len(se.block)
+	se.block = append(se.block, make([]byte, stringsReadSize)...)
+	n, err := lr.reader.Read(se.block[start:])
+	se.block = se.block[:start+n]
+	lr.numBytesRead += int64(n)
+	if err == io.EOF {
+		lr.isFileEnded = true
+	} else if err != nil {
+		lr.err = err
+	}
+	isEnded := lr.isFileEnded || (lr.err != nil)
+
+	pos := 0
+	for ; pos < len(se.block); pos++ {
+		if !isEnded && (len(se.block)-pos < utf8.UTFMax) {
+			break
+		}
+		offset := se.blockOffset + int64(pos)
+
+		if offset >= se.nextUTF8Offset {
+			r, size := utf8.DecodeRune(se.block[pos:])
+			se.nextUTF8Offset = offset + int64(size)
+			isPrintable := isPrintableStringRune(r) && ((r != utf8.RuneError) || (size > 1))
+			lr.stepStringRun(&se.utf8Run, r, offset, isPrintable)
+		}
+
+		// Each UTF-16 run steps through every other byte.
+		run := &se.utf16Runs[offset%2]
+		if pos+1 < len(se.block) {
+			r := rune(se.block[pos]) | rune(se.block[pos+1])<<8
+			lr.stepStringRun(run, r, offset, (r < 0x100) && isPrintableStringRune(r))
+		} else {
+			lr.endStringRun(run)
+		}
+	}
+
+	if isEnded {
+		lr.endStringRun(&se.utf8Run)
+		lr.endStringRun(&se.utf16Runs[0])
+		lr.endStringRun(&se.utf16Runs[1])
+	}
+	se.block = append(se.block[:0], se.block[pos:]...)
+	se.blockOffset += int64(pos)
+}
+
+func isPrintableStringRune(r rune) bool {
+	return (r == '\t') || ((r >= ' ') && unicode.IsPrint(r))
+}
+
+func (lr *lineReader) stepStringRun(run *stringRun, r rune, offset int64, isPrintable bool) {
+	if !isPrintable {
+		lr.endStringRun(run)
+		return
+	}
+	if run.length == 0 {
+		run.offset = offset
+	}
+	var encoded [utf8.UTFMax]byte
+	run.text = append(run.text, encoded[:utf8.EncodeRune(encoded[:], r)]...)
+	run.length++
+
+	// Long runs are cut so that memory use stays bounded.
+	if len(run.text) >= lr.maxLineLength {
+		lr.endStringRun(run)
+	}
+}
+
+// Ends the run, keeping it as a string if long enough.
+func (lr *lineReader) endStringRun(run *stringRun) {
+	se := lr.strings
+	if run.length >= se.minLength {
+		chunk := lineChunk{Line: Line{Text: string(run.text)}, offset: run.offset}
+		pos := len(se.found)
+		for (pos > 0) && (se.found[pos-1].offset > chunk.offset) {
+			pos--
+		}
+		se.found = append(se.found, lineChunk{})
+		copy(se.found[pos+1:], se.found[pos:])
+		se.found[pos] = chunk
+	}
+	run.text = run.text[:0]
+	run.length = 0
+}
+
+/**************************************************************************/
@@ -0,0 +1,86 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+/**************************************************************************/
+
+// Printable strings.
+
+func searchStringsForTest(t *testing.T, data string, setOptions func(options *Options)) []string {
+	t.Helper()
+	options := newTestOptions(fstest.MapFS{"data.bin": {Data: []byte(data)}})
+	options.SearchStrings = []string{"e"}
+	options.Strings = true
+	setOptions(&options)
+
+	var got []string
+	for _, result := range searchForTest(t, options) {
+		if result.Kind == ResultLine {
+			got = append(got, fmt.Sprintf("%v@%v %q", result.LineNumber, result.Offset, result.Text))
+		}
+	}
+	return got
+}
+
+func TestStrings(t *testing.T) {
+	utf16 := "w\x00i\x00d\x00e\x00 \x00t\x00e\x00x\x00t\x00"
+	data := "\x00\x01hello world\x00\x02abe\x00\xff" + utf16 + "\x00\x00\x7fnone\x80\x81héllo\n"
+	got := searchStringsForTest(t, data, func(*Options) {})
+	want := []string{`1@2 "hello world"`, `2@20 "wide text"`, `3@41 "none"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = searchStringsForTest(t, data, func(options *Options) {
+		options.SearchStrings = []string{"é"}
+		options.MinStringLength = 3
+	})
+	want = []string{`5@47 "héllo"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("minimum length 3: got %q, want %q", got, want)
+	}
+}
+
+func TestStringsAcrossReads(t *testing.T) {
+	data := make([]byte, 2*stringsReadSize)
+	copy(data[stringsReadSize-3:], "straddle")
+	copy(data[len(data)-4:], "tail")
+	got := searchStringsForTest(t, string(data), func(options *Options) {
+		options.SearchStrings = []string{"a"}
+	})
+	want := []string{fmt.Sprintf(`1@%v "straddle"`, stringsReadSize-3), fmt.Sprintf(`2@%v "tail"`, len(data)-4)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+/**************************************************************************/
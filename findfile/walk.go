@@ -0,0 +1,249 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2016 Lau, Chok Sheak (for software "findfile")
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package findfile
+
+import (
+	"container/heap"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+/**************************************************************************/
+
+// Dir tree nodes.
+
+// A walkNode is a dir or file waiting to be searched. Its order is the list
+// of child indexes leading to it from the starting dir, so comparing orders
+// gives the depth-first order of the nodes.
+type walkNode struct {
+	fileInfo fs.FileInfo
+	depth    int
+	path     string
+	relPath  string
+	ignores  *ignoreList
+	parent   *walkNode
+	order    []int
+	results  []*Result
+	children []*walkNode
+
+	// Given in Options.Paths, so a dir is not walked into.
+	isListed bool
+
+	// Identity of the file, only known when following links.
+	id    fileID
+	hasID bool
+
+	// Closed once the node has been searched and its children are known.
+	done chan struct{}
+}
+
+// The relPath is the slash-separated path relative to the starting dir,
+// and ignores are the ignore files that apply to the node.
+func newWalkNode(fileInfo fs.FileInfo, depth int, path, relPath string, ignores *ignoreList, parent *walkNode) *walkNode {
+	node := &walkNode{
+		fileInfo: fileInfo,
+		depth:    depth,
+		path:     path,
+		relPath:  relPath,
+		ignores:  ignores,
+		parent:   parent,
+		done:     make(chan struct{}),
+	}
+	if parent != nil {
+		node.order = make([]int, len(parent.order)+1)
+		copy(node.order, parent.order)
+		node.order[len(parent.order)] = len(parent.children)
+	}
+	return node
+}
+
+func (node *walkNode) isBefore(other *walkNode) bool {
+	return isOrderBefore(node.order, other.order)
+}
+
+func isOrderBefore(order, other []int) bool {
+	for i := 0; i < len(order) && i < len(other); i++ {
+		if order[i] != other[i] {
+			return order[i] < other[i]
+		}
+	}
+
+	// A parent comes before its children.
+	return len(order) < len(other)
+}
+
+func isSameOrder(order, other []int) bool {
+	return !isOrderBefore(order, other) && !isOrderBefore(other, order)
+}
+
+// Tells whether the dir is the node itself or one of its parents, which
+// would make a link to it loop forever.
+func (node *walkNode) isWithinDir(dirInfo fs.FileInfo) bool {
+	for n := node; n != nil; n = n.parent {
+		if os.SameFile(n.fileInfo, dirInfo) {
+			return true
+		}
+	}
+	return false
+}
+
+/**************************************************************************/
+
+// Work queue.
+
+// Always handing out the first node in depth-first order keeps the workers
+// close to where the results are being emitted, so that few results need
+// to be held in memory.
+type walkNodeHeap []*walkNode
+
+func (h walkNodeHeap) Len() int            { return len(h) }
+func (h walkNodeHeap) Less(i, j int) bool  { return h[i].isBefore(h[j]) }
+func (h walkNodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *walkNodeHeap) Push(x interface{}) { *h = append(*h, x.(*walkNode)) }
+
+func (h *walkNodeHeap) Pop() interface{} {
+	old := *h
+	node := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return node
+}
+
+type walkQueue struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	nodes walkNodeHeap
+
+	// Number of nodes queued or being searched.
+	numPending int
+	closed     bool
+}
+
+func newWalkQueue() *walkQueue {
+	q := &walkQueue{}
+	q.cond = sync.NewCond(&q.mutex)
+	return q
+}
+
+func (q *walkQueue) push(node *walkNode) {
+	q.mutex.Lock()
+	heap.Push(&q.nodes, node)
+	q.numPending++
+	q.mutex.Unlock()
+	q.cond.Signal()
+}
+
+// Blocks until a node is available. Returns false when there is no more
+// work, either because every node has been searched or the queue is closed.
+func (q *walkQueue) pop() (*walkNode, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for len(q.nodes) == 0 && q.numPending > 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.nodes) == 0 || q.closed {
+		return nil, false
+	}
+	return heap.Pop(&q.nodes).(*walkNode), true
+}
+
+// Marks a popped node as searched, after its children have been pushed.
+func (q *walkQueue) finish() {
+	q.mutex.Lock()
+	q.numPending--
+	isDone := (q.numPending == 0)
+	q.mutex.Unlock()
+
+	if isDone {
+		q.cond.Broadcast()
+	}
+}
+
+func (q *walkQueue) close() {
+	q.mutex.Lock()
+	q.closed = true
+	q.mutex.Unlock()
+	q.cond.Broadcast()
+}
+
+/**************************************************************************/
+
+// Emitting results in order.
+
+// Waits for each node in depth-first order and passes on its results.
+func (run *searchRun) emitResults(root *walkNode) {
+	stack := []*walkNode{root}
+
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		select {
+		case <-node.done:
+		case <-run.ctx.Done():
+			return
+		}
+
+		// Only the first path to a file in depth-first order is kept.
+		if run.isAlreadySearched(node, true) {
+			if run.options.ReportDuplicates && !run.emit(run.newDuplicateResult(node)) {
+				return
+			}
+			continue
+		}
+
+		for _, result := range node.results {
+			if !run.emit(result) {
+				return
+			}
+		}
+
+		// Maintain correct order for DFS.
+		for i := len(node.children) - 1; i >= 0; i-- {
+			stack = append(stack, node.children[i])
+		}
+
+		// Let the garbage collector have what we no longer need.
+		node.results = nil
+		node.children = nil
+	}
+}
+
+// Passes on the result, returning false if the search must stop.
+func (run *searchRun) emit(result *Result) bool {
+	run.resultMutex.Lock()
+	err := run.resultFunc(result)
+	run.resultMutex.Unlock()
+
+	if err != nil {
+		run.fail(err)
+		return false
+	}
+	return true
+}
+
+/**************************************************************************/